@@ -2,8 +2,10 @@ package bean
 
 // Created in 2025-03-23 16:11.
 // @author Horace
-// Options 配置选项
 
+import "github.com/horacedh/cronjob-executor/task"
+
+// Options 配置选项
 type ExecutorOptions struct {
 	// address 调度平台地址，例如：http://127.0.0.1:9527
 	Address string
@@ -17,6 +19,24 @@ type ExecutorOptions struct {
 	Tag string
 	// signKey 签名Key
 	SignKey string
+	// AdminReadKey 管理接口只读签名Key，非本机访问查询类管理接口时可以使用此Key签名，修改状态的管理接口只能使用SignKey签名，为空时查询类管理接口也只能使用SignKey签名
+	AdminReadKey string
+	// Standalone 单机模式，不依赖调度器，由执行器自己解析CRON表达式生成任务，并在本地执行过期策略和失败策略，适合本地开发、测试和小型部署
+	Standalone bool
+	// OrphanStrategy 孤儿任务策略，启动时对比调度器中本应用和标签下已注册的任务，处理代码中已经不存在的任务，默认不处理
+	OrphanStrategy OrphanStrategy
+	// RegisterInterval 检查注册状态的间隔时间，毫秒，默认30秒，执行器未注册或者调度器要求重新注册时注册执行器，任务变化或者调度器要求重新注册时注册任务
+	RegisterInterval int
+	// HeartbeatInterval 心跳间隔时间，毫秒，默认3秒
+	HeartbeatInterval int
+	// ShutdownTimeout 停机超时时间，毫秒，默认30秒，超过此时间还在执行的任务会被取消并提交失败结果，未到期的任务通知调度器取消
+	ShutdownTimeout int
+	// DedupTTL 已完成任务的去重记录保留时间，毫秒，默认10分钟，调度器在此时间内重复分发同一个任务日志ID时不会重复执行
+	DedupTTL int
+	// CheckpointStore 检查点存储，默认保存在本地文件中
+	CheckpointStore CheckpointStore
+	// CheckpointDir 本地文件检查点存储的目录，默认为checkpoints
+	CheckpointDir string
 }
 
 // RouterStrategy 路由策略枚举定义
@@ -49,6 +69,55 @@ const (
 	FailureDiscard FailureStrategy = 2
 )
 
+// OrphanStrategy 孤儿任务策略枚举定义，孤儿任务是指调度器中已注册，但执行器代码中已经不存在的任务
+type OrphanStrategy int
+
+const (
+	// OrphanIgnore 不检查孤儿任务
+	OrphanIgnore OrphanStrategy = 0
+	// OrphanFlag 只记录告警日志，并在管理接口中展示
+	OrphanFlag OrphanStrategy = 1
+	// OrphanRetire 从调度器中注销孤儿任务
+	OrphanRetire OrphanStrategy = 2
+)
+
+// ChainMode 后续任务触发方式枚举定义
+type ChainMode int
+
+const (
+	// ChainLocal 在本执行器上直接执行后续任务，不经过调度器，不发送任务结果，也不按照失败策略重试
+	ChainLocal ChainMode = 0
+	// ChainScheduler 通过OpenApi请求调度器触发后续任务，由调度器分发，后续任务可以不在本执行器上
+	ChainScheduler ChainMode = 1
+)
+
+// CheckpointStore 检查点存储枚举定义
+type CheckpointStore int
+
+const (
+	// CheckpointFile 保存在执行器本地文件中
+	CheckpointFile CheckpointStore = 0
+	// CheckpointOpenApi 通过OpenApi保存在调度器中，不占用执行器本地磁盘，与本地文件一样只有本地重试可以恢复
+	CheckpointOpenApi CheckpointStore = 1
+)
+
+const (
+	// DefaultRegisterInterval 默认检查注册状态的间隔时间，毫秒
+	DefaultRegisterInterval = 30 * 1000
+	// DefaultHeartbeatInterval 默认心跳间隔时间，毫秒
+	DefaultHeartbeatInterval = 3 * 1000
+	// DefaultShutdownTimeout 默认停机超时时间，毫秒
+	DefaultShutdownTimeout = 30 * 1000
+	// DefaultDedupTTL 默认去重记录保留时间，毫秒
+	DefaultDedupTTL = 10 * 60 * 1000
+	// DefaultCheckpointDir 默认本地文件检查点存储的目录
+	DefaultCheckpointDir = "checkpoints"
+	// MaxExpireTime 最大过期时间，毫秒
+	MaxExpireTime = 5 * 60 * 1000
+	// MaxTimeout 最大任务超时时间，毫秒
+	MaxTimeout = 10 * 1000
+)
+
 // TaskOptions 任务配置
 type TaskOptions struct {
 	// Name 任务名称
@@ -67,8 +136,27 @@ type TaskOptions struct {
 	MaxRetryCount int
 	// FailureRetryInterval 失败重试间隔时间，毫秒
 	FailureRetryInterval int
-	// Timeout  任务超时时间，超过此时间没有反馈执行结果给调度器，则认为执行器执行失败，调度器按照策略进行重试，单位毫秒，最大10秒钟，如果是消耗大量时间的任务，建立使用独立线程池运行
+	// Timeout  任务超时时间，超过此时间没有反馈执行结果给调度器，则认为执行器执行失败，调度器按照策略进行重试，单位毫秒，最大10秒钟，如果是消耗大量时间的任务，建立使用独立线程池运行。
+	// 处理器通过params.Context()感知此超时时间，内置的ShellTask和SqlTask可以通过ExecutionTimeout单独设置执行超时时间
 	Timeout int
+	// LocalRetryCount 执行器本地重试次数，默认0不在本地重试，失败后先在本地重试，全部失败后才将最终结果发送给调度器，之后再由调度器按照失败策略处理
+	LocalRetryCount int
+	// LocalRetryInterval 本地第一次重试的间隔时间，毫秒，默认1秒
+	LocalRetryInterval int
+	// LocalRetryMultiplier 本地重试间隔时间的退避倍数，默认2，为1时固定间隔重试
+	LocalRetryMultiplier int
+	// LocalRetryMaxInterval 本地重试的最大间隔时间，毫秒，默认30秒
+	LocalRetryMaxInterval int
+	// LocalRetryClassifier 本地重试的错误分类器，判断失败是否可以重试，默认除了任务被取消和错误分类为不可重试以外的失败都重试
+	LocalRetryClassifier task.RetryClassifier `json:"-"`
+	// OnSuccess 执行成功后触发的后续任务，使用任务名称或者任务方法，上游任务的输出数据作为后续任务的参数。
+	// 分片任务的每个分片分别触发，后续任务使用与上游相同的页码和总页数，只处理对应的分片
+	OnSuccess []string
+	// OnFailure 最终执行失败后触发的后续任务，使用任务名称或者任务方法，分片任务与OnSuccess一样每个分片分别触发。
+	// 调度器不下发重试次数，无法判断重试是否已经结束，因此连接调度器时必须将失败策略设置为丢弃，只有单机模式支持在达到最大重试次数后触发
+	OnFailure []string
+	// ChainMode 后续任务的触发方式，默认在本执行器上直接执行
+	ChainMode ChainMode
 	// Remark 任务备注，主要是用来描述任务详情，用来做什么样的任务？方便后期维护和管理
 	Remark string
 }
@@ -109,3 +197,126 @@ type TaskRegisterParams struct {
 	Tenant               string `json:"tenant"`
 	Timeout              int    `json:"timeout"`
 }
+
+// TaskUnregisterParams 任务注销参数
+type TaskUnregisterParams struct {
+	Tenant  string   `json:"tenant"`
+	AppName string   `json:"appName"`
+	Tag     string   `json:"tag"`
+	Methods []string `json:"methods"`
+}
+
+// TaskListParams 查询调度器中已注册任务的参数
+type TaskListParams struct {
+	Tenant  string `json:"tenant"`
+	AppName string `json:"appName"`
+	Tag     string `json:"tag"`
+}
+
+// TaskTriggerParams 请求调度器触发任务的参数
+type TaskTriggerParams struct {
+	// Tenant 租户编码
+	Tenant string `json:"tenant"`
+	// AppName 应用名
+	AppName string `json:"appName"`
+	// Tag 执行器标签
+	Tag string `json:"tag"`
+	// Name 任务名称或者任务方法
+	Name string `json:"name"`
+	// Params 任务自定义参数
+	Params string `json:"params"`
+	// ParentTaskLogId 上游任务的任务日志ID
+	ParentTaskLogId int64 `json:"parentTaskLogId"`
+	// Page 上游任务的页码，后续任务只处理对应的分片
+	Page int32 `json:"page"`
+	// Total 上游任务的总页数
+	Total int32 `json:"total"`
+}
+
+// RegisterStatus 执行器注册状态
+type RegisterStatus struct {
+	// Success 执行器是否注册成功
+	Success bool `json:"success"`
+	// LastRegisterTime 最近一次注册执行器的时间，毫秒
+	LastRegisterTime int64 `json:"lastRegisterTime"`
+	// TaskRegistered 任务是否注册成功
+	TaskRegistered bool `json:"taskRegistered"`
+	// LastTaskRegisterTime 最近一次注册任务的时间，毫秒
+	LastTaskRegisterTime int64 `json:"lastTaskRegisterTime"`
+	// TaskHash 最近一次注册成功的任务集合的内容哈希，任务集合不变时不会重复注册
+	TaskHash string `json:"taskHash"`
+	// OrphanTasks 启动时检查到的孤儿任务方法，OrphanRetire策略下为已注销的任务
+	OrphanTasks []string `json:"orphanTasks"`
+}
+
+// HeartbeatParams 心跳参数，除了address以外的字段都是新增的负载信息，旧版本调度器会忽略这些字段
+type HeartbeatParams struct {
+	// Address 执行器地址，host:port
+	Address string `json:"address"`
+	// Version 执行器SDK版本
+	Version string `json:"version"`
+	// StartTime 执行器启动时间，毫秒
+	StartTime int64 `json:"startTime"`
+	// Uptime 执行器运行时长，毫秒
+	Uptime int64 `json:"uptime"`
+	// RunningTasks 正在执行的任务数量
+	RunningTasks int `json:"runningTasks"`
+	// QueueSize 队列中等待执行的任务数量
+	QueueSize int `json:"queueSize"`
+	// Goroutines goroutine数量
+	Goroutines int `json:"goroutines"`
+	// NumCPU CPU核数
+	NumCPU int `json:"numCpu"`
+	// LoadAverage 系统最近1分钟的平均负载，无法获取时为-1
+	LoadAverage float64 `json:"loadAverage"`
+	// MemoryAlloc 已分配的堆内存，字节
+	MemoryAlloc uint64 `json:"memoryAlloc"`
+	// MemorySys 从操作系统获取的内存，字节
+	MemorySys uint64 `json:"memorySys"`
+}
+
+// HeartbeatResponse 调度器的心跳响应数据
+type HeartbeatResponse struct {
+	// Reregister 调度器要求执行器重新注册执行器和任务，例如调度器重启后丢失了注册信息
+	Reregister bool `json:"reregister"`
+}
+
+// CheckpointParams 检查点参数，游标在JSON中为Base64编码
+type CheckpointParams struct {
+	// Tenant 租户编码
+	Tenant string `json:"tenant"`
+	// AppName 应用名
+	AppName string `json:"appName"`
+	// TaskId 任务ID
+	TaskId int64 `json:"taskId"`
+	// Page 分片页码
+	Page int32 `json:"page"`
+	// ScheduleTime 调度时间槽，毫秒
+	ScheduleTime int64 `json:"scheduleTime"`
+	// Cursor 游标，查询和删除时为空
+	Cursor []byte `json:"cursor,omitempty"`
+}
+
+// HeartbeatStatus 执行器心跳状态
+type HeartbeatStatus struct {
+	// Success 最近一次心跳是否成功
+	Success bool `json:"success"`
+	// LastHeartbeatTime 最近一次心跳的时间，毫秒
+	LastHeartbeatTime int64 `json:"lastHeartbeatTime"`
+	// LastSuccessTime 最近一次心跳成功的时间，毫秒
+	LastSuccessTime int64 `json:"lastSuccessTime"`
+}
+
+// RunNowOptions 本地立即执行任务的参数
+type RunNowOptions struct {
+	// Params 任务自定义参数
+	Params string `json:"params"`
+	// Page 页码
+	Page int32 `json:"page"`
+	// Total 总页数
+	Total int32 `json:"total"`
+	// TaskLogId 任务日志ID，需要将结果发送给调度器时，传入调度器中已存在的任务日志ID
+	TaskLogId int64 `json:"taskLogId"`
+	// Report 是否将任务结果发送给调度器，默认不发送，为true时必须传入TaskLogId
+	Report bool `json:"report"`
+}
@@ -0,0 +1,172 @@
+package cronjob
+
+// Created in 2026-10-18 17:20.
+// @author Horace
+
+import (
+	"errors"
+	"fmt"
+	logger "github.com/cihub/seelog"
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/config"
+	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/utils"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NewExecutorClientFromConfig 读取YAML或者JSON配置文件（支持环境变量覆盖）创建执行器客户端，配置中的任务按照处理器名称绑定handlers中的任务处理器
+func NewExecutorClientFromConfig(path string, handlers map[string]task.TaskHandler) (ExecutorClient, error) {
+	executorConfig, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewExecutorClientWithConfig(executorConfig, handlers)
+}
+
+// NewExecutorClientWithConfig 根据配置创建执行器客户端并添加任务，先校验全部配置，有任何问题时返回所有问题且不会创建客户端
+func NewExecutorClientWithConfig(executorConfig *config.Config, handlers map[string]task.TaskHandler) (ExecutorClient, error) {
+	options := executorConfig.ExecutorOptions()
+	definitions, err := bindTasks(executorConfig, handlers, options)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := NewExecutorClient(&options)
+	if err != nil {
+		return nil, err
+	}
+	if err = client.AddTasks(definitions...); err != nil {
+		return nil, err
+	}
+	logger.Infof("load cron-job config success, effective config:\n%s", EffectiveConfig(executorConfig).Dump())
+	return client, nil
+}
+
+// WatchConfig 按照固定频率检查配置文件的修改时间和大小，文件变化后重新加载任务，interval小于等于0时默认10秒
+func (client *executorClientImpl) WatchConfig(path string, handlers map[string]task.TaskHandler, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second * 10
+	}
+
+	var modTime time.Time
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		modTime, size = info.ModTime(), info.Size()
+	}
+
+	logger.Infof("watch cron-job config, path:%s, interval:%s", path, interval)
+	var mu sync.Mutex
+	utils.GetScheduler().ScheduleAtFixedRate(interval, false, func() {
+		// 避免加载耗时较长时并发加载
+		if !mu.TryLock() {
+			return
+		}
+		defer mu.Unlock()
+
+		info, err := os.Stat(path)
+		if err != nil {
+			logger.Warnf("watch cron-job config, stat file failed, path:%s, err:%v", path, err)
+			return
+		}
+		if info.ModTime().Equal(modTime) && info.Size() == size {
+			return
+		}
+		modTime, size = info.ModTime(), info.Size()
+
+		if err = client.reloadConfig(path, handlers); err != nil {
+			logger.Errorf("reload cron-job config failed, tasks not changed, path:%s, err:%v", path, err)
+		}
+	})
+}
+
+// reloadConfig 重新加载配置文件中的任务
+func (client *executorClientImpl) reloadConfig(path string, handlers map[string]task.TaskHandler) error {
+	executorConfig, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	options := executorConfig.ExecutorOptions()
+	setDefaultExecutorOptions(&options)
+	if options != client.options {
+		logger.Warnf("cron-job executor config changed, restart is required to take effect, path:%s", path)
+		options = client.options
+	}
+
+	definitions, err := bindTasks(executorConfig, handlers, options)
+	if err != nil {
+		return err
+	}
+	return client.ReloadTasks(definitions...)
+}
+
+// EffectiveConfig 获取生效的配置，未设置的字段填充为默认值
+func EffectiveConfig(executorConfig *config.Config) *config.Config {
+	effective := &config.Config{Executor: executorConfig.Executor}
+	options := executorConfig.ExecutorOptions()
+	setDefaultExecutorOptions(&options)
+	effective.Executor.Tag = options.Tag
+	effective.Executor.RegisterInterval = options.RegisterInterval
+	effective.Executor.HeartbeatInterval = options.HeartbeatInterval
+	effective.Executor.ShutdownTimeout = options.ShutdownTimeout
+	effective.Executor.DedupTTL = options.DedupTTL
+	effective.Executor.CheckpointDir = options.CheckpointDir
+	for _, taskConfig := range executorConfig.Tasks {
+		options, _ := taskConfig.TaskOptions()
+		setDefaultTaskOptions(&options)
+		effective.Tasks = append(effective.Tasks, config.NewTaskConfig(taskConfig.Handler, options))
+	}
+	return effective
+}
+
+// bindTasks 按照处理器名称绑定任务处理器，并校验执行器配置和任务配置
+func bindTasks(executorConfig *config.Config, handlers map[string]task.TaskHandler, options bean.ExecutorOptions) ([]TaskDefinition, error) {
+	var errs []error
+	if err := ValidateExecutorOptions(&options); err != nil {
+		errs = append(errs, err)
+	}
+	if err := executorConfig.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	// 使用临时的客户端逐个校验任务配置，不影响单例
+	dryRun := newExecutorClient(options)
+	definitions := make([]TaskDefinition, 0, len(executorConfig.Tasks))
+	for index, taskConfig := range executorConfig.Tasks {
+		taskOptions, _ := taskConfig.TaskOptions()
+		handler, ok := handlers[taskConfig.Handler]
+		if !ok {
+			if taskConfig.Handler != "" {
+				errs = append(errs, fmt.Errorf("tasks[%d] %s: unknown handler %q, registered handlers: %v", index, taskConfig.Name, taskConfig.Handler, handlerNames(handlers)))
+			}
+			if err := ValidateTaskOptions(taskOptions); err != nil {
+				errs = append(errs, fmt.Errorf("tasks[%d]: %w", index, err))
+			}
+			continue
+		}
+
+		definition := TaskDefinition{Handler: handler, Options: taskOptions}
+		if err := dryRun.AddTasks(definition); err != nil {
+			errs = append(errs, fmt.Errorf("tasks[%d]: %w", index, err))
+			continue
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid cron-job config: %w", errors.Join(errs...))
+	}
+	return definitions, nil
+}
+
+// handlerNames 获取排序后的处理器名称
+func handlerNames(handlers map[string]task.TaskHandler) []string {
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,380 @@
+package config
+
+// Created in 2026-10-18 16:50.
+// @author Horace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/horacedh/cronjob-executor/bean"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix 环境变量前缀，执行器配置为 CRONJOB_<KEY>，任务配置为 CRONJOB_TASK_<HANDLER>_<KEY>
+const EnvPrefix = "CRONJOB_"
+
+// 配置文件格式
+const (
+	// FormatYAML YAML格式
+	FormatYAML = "yaml"
+	// FormatJSON JSON格式
+	FormatJSON = "json"
+)
+
+// Config 执行器配置文件
+type Config struct {
+	// Executor 执行器配置
+	Executor ExecutorConfig `yaml:"executor" json:"executor"`
+	// Tasks 任务配置
+	Tasks []TaskConfig `yaml:"tasks" json:"tasks"`
+}
+
+// ExecutorConfig 执行器配置，对应 bean.ExecutorOptions
+type ExecutorConfig struct {
+	// Address 调度平台地址，例如：http://127.0.0.1:9527
+	Address string `yaml:"address" json:"address" env:"ADDRESS"`
+	// Tenant 租户编码
+	Tenant string `yaml:"tenant" json:"tenant" env:"TENANT"`
+	// AppName 应用名，一般英文代号
+	AppName string `yaml:"appName" json:"appName" env:"APP_NAME"`
+	// AppDesc 应用描述
+	AppDesc string `yaml:"appDesc" json:"appDesc" env:"APP_DESC"`
+	// Tag 执行器标签
+	Tag string `yaml:"tag" json:"tag" env:"TAG"`
+	// SignKey 签名Key
+	SignKey string `yaml:"signKey" json:"signKey" env:"SIGN_KEY"`
+	// AdminReadKey 管理接口只读签名Key
+	AdminReadKey string `yaml:"adminReadKey,omitempty" json:"adminReadKey,omitempty" env:"ADMIN_READ_KEY"`
+	// Standalone 单机模式
+	Standalone bool `yaml:"standalone" json:"standalone" env:"STANDALONE"`
+	// OrphanStrategy 孤儿任务策略：ignore、flag、retire
+	OrphanStrategy string `yaml:"orphanStrategy,omitempty" json:"orphanStrategy,omitempty" env:"ORPHAN_STRATEGY"`
+	// RegisterInterval 检查注册状态的间隔时间，毫秒
+	RegisterInterval int `yaml:"registerInterval,omitempty" json:"registerInterval,omitempty" env:"REGISTER_INTERVAL"`
+	// HeartbeatInterval 心跳间隔时间，毫秒
+	HeartbeatInterval int `yaml:"heartbeatInterval,omitempty" json:"heartbeatInterval,omitempty" env:"HEARTBEAT_INTERVAL"`
+	// ShutdownTimeout 停机超时时间，毫秒
+	ShutdownTimeout int `yaml:"shutdownTimeout,omitempty" json:"shutdownTimeout,omitempty" env:"SHUTDOWN_TIMEOUT"`
+	// DedupTTL 已完成任务的去重记录保留时间，毫秒
+	DedupTTL int `yaml:"dedupTtl,omitempty" json:"dedupTtl,omitempty" env:"DEDUP_TTL"`
+	// CheckpointStore 检查点存储：file、openapi
+	CheckpointStore string `yaml:"checkpointStore,omitempty" json:"checkpointStore,omitempty" env:"CHECKPOINT_STORE"`
+	// CheckpointDir 本地文件检查点存储的目录
+	CheckpointDir string `yaml:"checkpointDir,omitempty" json:"checkpointDir,omitempty" env:"CHECKPOINT_DIR"`
+}
+
+// TaskConfig 任务配置，对应 bean.TaskOptions，通过Handler名称绑定代码中的任务处理器
+type TaskConfig struct {
+	// Handler 任务处理器名称，与代码中注册的处理器名称对应
+	Handler string `yaml:"handler" json:"handler"`
+	// Name 任务名称
+	Name string `yaml:"name" json:"name" env:"NAME"`
+	// Cron CRON表达式
+	Cron string `yaml:"cron" json:"cron" env:"CRON"`
+	// RouterStrategy 路由策略：random、sharding
+	RouterStrategy string `yaml:"routerStrategy,omitempty" json:"routerStrategy,omitempty" env:"ROUTER_STRATEGY"`
+	// ExpiredStrategy 过期策略：discard、execute
+	ExpiredStrategy string `yaml:"expiredStrategy,omitempty" json:"expiredStrategy,omitempty" env:"EXPIRED_STRATEGY"`
+	// ExpireTime 过期时间，毫秒
+	ExpireTime int `yaml:"expireTime,omitempty" json:"expireTime,omitempty" env:"EXPIRE_TIME"`
+	// FailureStrategy 失败策略：retry、discard
+	FailureStrategy string `yaml:"failureStrategy,omitempty" json:"failureStrategy,omitempty" env:"FAILURE_STRATEGY"`
+	// MaxRetryCount 失败最大重试次数
+	MaxRetryCount int `yaml:"maxRetryCount,omitempty" json:"maxRetryCount,omitempty" env:"MAX_RETRY_COUNT"`
+	// FailureRetryInterval 失败重试间隔时间，毫秒
+	FailureRetryInterval int `yaml:"failureRetryInterval,omitempty" json:"failureRetryInterval,omitempty" env:"FAILURE_RETRY_INTERVAL"`
+	// Timeout 任务超时时间，毫秒
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty" env:"TIMEOUT"`
+	// LocalRetryCount 执行器本地重试次数
+	LocalRetryCount int `yaml:"localRetryCount,omitempty" json:"localRetryCount,omitempty" env:"LOCAL_RETRY_COUNT"`
+	// LocalRetryInterval 本地第一次重试的间隔时间，毫秒
+	LocalRetryInterval int `yaml:"localRetryInterval,omitempty" json:"localRetryInterval,omitempty" env:"LOCAL_RETRY_INTERVAL"`
+	// LocalRetryMultiplier 本地重试间隔时间的退避倍数
+	LocalRetryMultiplier int `yaml:"localRetryMultiplier,omitempty" json:"localRetryMultiplier,omitempty" env:"LOCAL_RETRY_MULTIPLIER"`
+	// LocalRetryMaxInterval 本地重试的最大间隔时间，毫秒
+	LocalRetryMaxInterval int `yaml:"localRetryMaxInterval,omitempty" json:"localRetryMaxInterval,omitempty" env:"LOCAL_RETRY_MAX_INTERVAL"`
+	// OnSuccess 执行成功后触发的后续任务
+	OnSuccess []string `yaml:"onSuccess,omitempty" json:"onSuccess,omitempty"`
+	// OnFailure 最终执行失败后触发的后续任务
+	OnFailure []string `yaml:"onFailure,omitempty" json:"onFailure,omitempty"`
+	// ChainMode 后续任务的触发方式：local、scheduler
+	ChainMode string `yaml:"chainMode,omitempty" json:"chainMode,omitempty" env:"CHAIN_MODE"`
+	// Remark 任务备注
+	Remark string `yaml:"remark,omitempty" json:"remark,omitempty" env:"REMARK"`
+}
+
+// 策略名称与枚举值的映射
+var (
+	routerStrategies  = map[string]bean.RouterStrategy{"random": bean.RANDOM, "sharding": bean.SHARDING}
+	expiredStrategies = map[string]bean.ExpiredStrategy{"discard": bean.ExpiredDiscard, "execute": bean.ExpiredExecute}
+	failureStrategies = map[string]bean.FailureStrategy{"retry": bean.FailureRetry, "discard": bean.FailureDiscard}
+	orphanStrategies  = map[string]bean.OrphanStrategy{"ignore": bean.OrphanIgnore, "flag": bean.OrphanFlag, "retire": bean.OrphanRetire}
+	checkpointStores  = map[string]bean.CheckpointStore{"file": bean.CheckpointFile, "openapi": bean.CheckpointOpenApi}
+	chainModes        = map[string]bean.ChainMode{"local": bean.ChainLocal, "scheduler": bean.ChainScheduler}
+)
+
+// Load 读取配置文件，根据扩展名识别YAML或者JSON格式，并使用环境变量覆盖
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file failed, path: %s: %w", path, err)
+	}
+
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = FormatYAML
+	case ".json":
+		format = FormatJSON
+	default:
+		return nil, fmt.Errorf("unsupported config file format, path: %s, expected .yaml, .yml or .json", path)
+	}
+
+	config, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("parse config file failed, path: %s: %w", path, err)
+	}
+	if err = config.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Parse 解析配置内容，不允许出现未知字段
+func Parse(data []byte, format string) (*Config, error) {
+	config := &Config{}
+	switch format {
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(config); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+	return config, nil
+}
+
+// ApplyEnv 使用环境变量覆盖配置，lookup一般传入 os.LookupEnv
+func (config *Config) ApplyEnv(lookup func(key string) (string, bool)) error {
+	var errs []error
+	errs = append(errs, applyEnv(reflect.ValueOf(&config.Executor).Elem(), EnvPrefix, lookup)...)
+	for index := range config.Tasks {
+		taskConfig := &config.Tasks[index]
+		if taskConfig.Handler == "" {
+			continue
+		}
+		prefix := EnvPrefix + "TASK_" + envName(taskConfig.Handler) + "_"
+		errs = append(errs, applyEnv(reflect.ValueOf(taskConfig).Elem(), prefix, lookup)...)
+	}
+	return errors.Join(errs...)
+}
+
+// Validate 校验配置，一次性返回所有问题，任务配置的取值范围在绑定处理器时校验
+func (config *Config) Validate() error {
+	var errs []error
+	if _, ok := orphanStrategies[strings.ToLower(config.Executor.OrphanStrategy)]; !ok && config.Executor.OrphanStrategy != "" {
+		errs = append(errs, fmt.Errorf("executor: unknown orphanStrategy %q", config.Executor.OrphanStrategy))
+	}
+	if _, ok := checkpointStores[strings.ToLower(config.Executor.CheckpointStore)]; !ok && config.Executor.CheckpointStore != "" {
+		errs = append(errs, fmt.Errorf("executor: unknown checkpointStore %q", config.Executor.CheckpointStore))
+	}
+	handlers := make(map[string]bool)
+	for index, taskConfig := range config.Tasks {
+		if taskConfig.Handler == "" {
+			errs = append(errs, fmt.Errorf("tasks[%d] %s: handler is required", index, taskConfig.Name))
+		} else if handlers[taskConfig.Handler] {
+			errs = append(errs, fmt.Errorf("tasks[%d] %s: duplicate handler %s", index, taskConfig.Name, taskConfig.Handler))
+		}
+		handlers[taskConfig.Handler] = true
+		if _, err := taskConfig.TaskOptions(); err != nil {
+			errs = append(errs, fmt.Errorf("tasks[%d] %s: %w", index, taskConfig.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ExecutorOptions 转换为执行器配置，未知的孤儿任务策略按照不处理转换，需要先调用Validate校验
+func (config *Config) ExecutorOptions() bean.ExecutorOptions {
+	return bean.ExecutorOptions{
+		Address:           config.Executor.Address,
+		Tenant:            config.Executor.Tenant,
+		AppName:           config.Executor.AppName,
+		AppDesc:           config.Executor.AppDesc,
+		Tag:               config.Executor.Tag,
+		SignKey:           config.Executor.SignKey,
+		AdminReadKey:      config.Executor.AdminReadKey,
+		Standalone:        config.Executor.Standalone,
+		OrphanStrategy:    orphanStrategies[strings.ToLower(config.Executor.OrphanStrategy)],
+		RegisterInterval:  config.Executor.RegisterInterval,
+		HeartbeatInterval: config.Executor.HeartbeatInterval,
+		ShutdownTimeout:   config.Executor.ShutdownTimeout,
+		DedupTTL:          config.Executor.DedupTTL,
+		CheckpointStore:   checkpointStores[strings.ToLower(config.Executor.CheckpointStore)],
+		CheckpointDir:     config.Executor.CheckpointDir,
+	}
+}
+
+// Dump 以YAML格式输出生效的配置，签名Key脱敏
+func (config *Config) Dump() string {
+	effective := *config
+	if effective.Executor.SignKey != "" {
+		effective.Executor.SignKey = "******"
+	}
+	if effective.Executor.AdminReadKey != "" {
+		effective.Executor.AdminReadKey = "******"
+	}
+	data, err := yaml.Marshal(&effective)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// TaskOptions 转换为任务配置，策略名称不区分大小写
+func (taskConfig *TaskConfig) TaskOptions() (bean.TaskOptions, error) {
+	options := bean.TaskOptions{
+		Name:                  taskConfig.Name,
+		Cron:                  taskConfig.Cron,
+		ExpireTime:            taskConfig.ExpireTime,
+		MaxRetryCount:         taskConfig.MaxRetryCount,
+		FailureRetryInterval:  taskConfig.FailureRetryInterval,
+		Timeout:               taskConfig.Timeout,
+		LocalRetryCount:       taskConfig.LocalRetryCount,
+		LocalRetryInterval:    taskConfig.LocalRetryInterval,
+		LocalRetryMultiplier:  taskConfig.LocalRetryMultiplier,
+		LocalRetryMaxInterval: taskConfig.LocalRetryMaxInterval,
+		OnSuccess:             taskConfig.OnSuccess,
+		OnFailure:             taskConfig.OnFailure,
+		Remark:                taskConfig.Remark,
+	}
+
+	var errs []error
+	if taskConfig.RouterStrategy != "" {
+		if options.RouterStrategy = routerStrategies[strings.ToLower(taskConfig.RouterStrategy)]; options.RouterStrategy == 0 {
+			errs = append(errs, fmt.Errorf("unknown routerStrategy %q, expected random or sharding", taskConfig.RouterStrategy))
+		}
+	}
+	if taskConfig.ExpiredStrategy != "" {
+		if options.ExpiredStrategy = expiredStrategies[strings.ToLower(taskConfig.ExpiredStrategy)]; options.ExpiredStrategy == 0 {
+			errs = append(errs, fmt.Errorf("unknown expiredStrategy %q, expected discard or execute", taskConfig.ExpiredStrategy))
+		}
+	}
+	if taskConfig.FailureStrategy != "" {
+		if options.FailureStrategy = failureStrategies[strings.ToLower(taskConfig.FailureStrategy)]; options.FailureStrategy == 0 {
+			errs = append(errs, fmt.Errorf("unknown failureStrategy %q, expected retry or discard", taskConfig.FailureStrategy))
+		}
+	}
+	if taskConfig.ChainMode != "" {
+		var ok bool
+		if options.ChainMode, ok = chainModes[strings.ToLower(taskConfig.ChainMode)]; !ok {
+			errs = append(errs, fmt.Errorf("unknown chainMode %q, expected local or scheduler", taskConfig.ChainMode))
+		}
+	}
+	return options, errors.Join(errs...)
+}
+
+// NewTaskConfig 根据任务配置构建配置文件中的任务配置，用于输出生效的配置
+func NewTaskConfig(handler string, options bean.TaskOptions) TaskConfig {
+	taskConfig := TaskConfig{
+		Handler:               handler,
+		Name:                  options.Name,
+		Cron:                  options.Cron,
+		ExpireTime:            options.ExpireTime,
+		MaxRetryCount:         options.MaxRetryCount,
+		FailureRetryInterval:  options.FailureRetryInterval,
+		Timeout:               options.Timeout,
+		LocalRetryCount:       options.LocalRetryCount,
+		LocalRetryInterval:    options.LocalRetryInterval,
+		LocalRetryMultiplier:  options.LocalRetryMultiplier,
+		LocalRetryMaxInterval: options.LocalRetryMaxInterval,
+		OnSuccess:             options.OnSuccess,
+		OnFailure:             options.OnFailure,
+		Remark:                options.Remark,
+	}
+	for name, value := range routerStrategies {
+		if value == options.RouterStrategy {
+			taskConfig.RouterStrategy = name
+		}
+	}
+	for name, value := range expiredStrategies {
+		if value == options.ExpiredStrategy {
+			taskConfig.ExpiredStrategy = name
+		}
+	}
+	for name, value := range failureStrategies {
+		if value == options.FailureStrategy {
+			taskConfig.FailureStrategy = name
+		}
+	}
+	if options.ChainMode != bean.ChainLocal {
+		for name, value := range chainModes {
+			if value == options.ChainMode {
+				taskConfig.ChainMode = name
+			}
+		}
+	}
+	return taskConfig
+}
+
+// applyEnv 按照字段的env标签使用环境变量覆盖结构体字段
+func applyEnv(value reflect.Value, prefix string, lookup func(key string) (string, bool)) []error {
+	var errs []error
+	for i := 0; i < value.NumField(); i++ {
+		tag := value.Type().Field(i).Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+		key := prefix + tag
+		env, ok := lookup(key)
+		if !ok {
+			continue
+		}
+
+		field := value.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(env)
+		case reflect.Int:
+			number, err := strconv.Atoi(env)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid environment variable %s=%q, expected an integer", key, env))
+				continue
+			}
+			field.SetInt(int64(number))
+		case reflect.Bool:
+			flag, err := strconv.ParseBool(env)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid environment variable %s=%q, expected a boolean", key, env))
+				continue
+			}
+			field.SetBool(flag)
+		}
+	}
+	return errs
+}
+
+// envName 将处理器名称转换为环境变量名称，非字母数字的字符替换为下划线
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(name))
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"github.com/horacedh/cronjob-executor/bean"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Created in 2026-10-18 17:45.
+// @author Horace
+
+var yamlConfig = `
+executor:
+  address: http://localhost:9527
+  tenant: horace
+  appName: go-example-executor
+  appDesc: Go示例执行器
+  signKey: 7d890a079948b196756rtf5452d2245t
+tasks:
+  - handler: demo-task
+    name: Go测试任务
+    cron: "* * * * * ?"
+    failureStrategy: discard
+  - handler: report.daily
+    name: 日报
+    cron: "0 0 2 * * ?"
+    maxRetryCount: 3
+`
+
+// TestLoad 测试读取配置文件并使用环境变量覆盖
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cronjob.yaml")
+	if err := os.WriteFile(path, []byte(yamlConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CRONJOB_TAG", "gray")
+	t.Setenv("CRONJOB_TASK_REPORT_DAILY_CRON", "0 30 3 * * ?")
+	t.Setenv("CRONJOB_TASK_REPORT_DAILY_MAX_RETRY_COUNT", "1")
+
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("load config failed, err: %v", err)
+	}
+	if err = config.Validate(); err != nil {
+		t.Fatalf("validate config failed, err: %v", err)
+	}
+	if config.Executor.Tag != "gray" || config.Tasks[1].Cron != "0 30 3 * * ?" || config.Tasks[1].MaxRetryCount != 1 {
+		t.Errorf("environment variables not applied, config: %+v", config)
+	}
+	options, _ := config.Tasks[0].TaskOptions()
+	if options.FailureStrategy != bean.FailureDiscard {
+		t.Errorf("failure strategy not converted, options: %+v", options)
+	}
+	if dump := config.Dump(); strings.Contains(dump, "7d890a079948b196756rtf5452d2245t") || !strings.Contains(dump, "report.daily") {
+		t.Errorf("unexpected dump:\n%s", dump)
+	}
+
+	t.Setenv("CRONJOB_TASK_REPORT_DAILY_TIMEOUT", "ten")
+	if _, err = Load(path); err == nil {
+		t.Errorf("invalid environment variable should fail")
+	}
+}
+
+// TestParseStrict 测试未知字段和非法策略
+func TestParseStrict(t *testing.T) {
+	if _, err := Parse([]byte("executor:\n  appname: demo\n"), FormatYAML); err == nil {
+		t.Errorf("unknown yaml field should fail")
+	}
+	if _, err := Parse([]byte(`{"tasks":[{"handler":"a","retry":1}]}`), FormatJSON); err == nil {
+		t.Errorf("unknown json field should fail")
+	}
+
+	config, err := Parse([]byte(`{"tasks":[{"handler":"a","routerStrategy":"roundRobin"},{"handler":"a"},{"name":"b"}]}`), FormatJSON)
+	if err != nil {
+		t.Fatalf("parse config failed, err: %v", err)
+	}
+	err = config.Validate()
+	for _, problem := range []string{"unknown routerStrategy", "duplicate handler", "handler is required"} {
+		if err == nil || !strings.Contains(err.Error(), problem) {
+			t.Errorf("problem %q not reported, err: %v", problem, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cronjob
+
+import (
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/task"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Created in 2026-10-18 18:40.
+// @author Horace
+
+// TestReloadTasks 测试运行时修改、移除任务以及重新加载配置文件
+func TestReloadTasks(t *testing.T) {
+	client := newExecutorClient(bean.ExecutorOptions{AppName: "reload", Standalone: true, Tag: "common"})
+	client.AddTask(DemoTask{}, bean.TaskOptions{Name: "demo", Cron: "0 0 * * * ?"})
+	client.AddTask(DemoTask1{}, bean.TaskOptions{Name: "demo1", Cron: "0 0 * * * ?"})
+
+	if err := client.UpdateTask(DemoTask{}, bean.TaskOptions{Name: "demo1", Cron: "0 0 * * * ?"}); err == nil {
+		t.Errorf("duplicate name should fail")
+	}
+	if err := client.UpdateTask(DemoTask{}, bean.TaskOptions{Name: "demo", Cron: "0 30 * * * ?"}); err != nil {
+		t.Fatalf("update task failed, err: %v", err)
+	}
+	if _, options := client.registry.Get("reload/cronjob.DemoTask.Handle"); options.Cron != "0 30 * * * ?" || options.Timeout != 10000 {
+		t.Errorf("task not updated, options: %v", options)
+	}
+	if err := client.RemoveTask("demo1"); err != nil {
+		t.Fatalf("remove task failed, err: %v", err)
+	}
+	if err := client.RemoveTask("demo1"); err == nil {
+		t.Errorf("remove missing task should fail")
+	}
+
+	path := filepath.Join(t.TempDir(), "cronjob.yaml")
+	handlers := map[string]task.TaskHandler{"demo": DemoTask{}, "demo1": DemoTask1{}}
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("executor:\n  appName: reload\n  standalone: true\ntasks:\n  - handler: demo1\n    name: demo1\n    cron: \"0 0 1 * * ?\"\n")
+	if err := client.reloadConfig(path, handlers); err != nil {
+		t.Fatalf("reload config failed, err: %v", err)
+	}
+	if options := client.registry.Options(); len(options) != 1 || options["reload/cronjob.DemoTask1.Handle"] == nil {
+		t.Errorf("tasks not replaced, options: %v", options)
+	}
+
+	// 配置有问题时不修改任务
+	write("executor:\n  appName: reload\n  standalone: true\ntasks:\n  - handler: demo\n    name: demo\n    cron: \"0 0 25 * * ?\"\n")
+	if err := client.reloadConfig(path, handlers); err == nil {
+		t.Errorf("invalid config should fail")
+	}
+	if options := client.registry.Options(); len(options) != 1 || options["reload/cronjob.DemoTask1.Handle"] == nil {
+		t.Errorf("tasks should not change when reload fails, options: %v", options)
+	}
+}
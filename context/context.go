@@ -3,6 +3,7 @@ package context
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Created in 2025-03-23 17:08.
@@ -10,6 +11,10 @@ import (
 
 var DispatcherStopped atomic.Bool = atomic.Bool{}
 var Shutdown atomic.Bool = atomic.Bool{}
+var Draining atomic.Bool = atomic.Bool{}
+var ShutdownDeadline atomic.Int64 = atomic.Int64{}
 var Version = "Go-1.0.0"
 var SignKey atomic.Value = atomic.Value{}
+var AdminReadKey atomic.Value = atomic.Value{}
 var WaitGroup sync.WaitGroup = sync.WaitGroup{}
+var StartTime = time.Now()
@@ -0,0 +1,121 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-18 14:40.
+// @author Horace
+
+// TestNext 测试下一次触发时间
+func TestNext(t *testing.T) {
+	location := time.FixedZone("CST", 8*3600)
+	from := time.Date(2026, 10, 18, 10, 15, 30, 500, location)
+	cases := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"* * * * * ? ", time.Date(2026, 10, 18, 10, 15, 31, 0, location)},
+		{"0 * * * * ?", time.Date(2026, 10, 18, 10, 16, 0, 0, location)},
+		{"0/20 * * * * ?", time.Date(2026, 10, 18, 10, 15, 40, 0, location)},
+		{"0 0 2 * * ?", time.Date(2026, 10, 19, 2, 0, 0, 0, location)},
+		{"0 30 9-17/2 ? * MON-FRI", time.Date(2026, 10, 19, 9, 30, 0, 0, location)},
+		{"0 0 0 1 JAN,JUL ?", time.Date(2027, 1, 1, 0, 0, 0, 0, location)},
+		{"0 0 12 ? * 1", time.Date(2026, 10, 18, 12, 0, 0, 0, location)},
+		{"0 0 0 29 2 ? 2028", time.Date(2028, 2, 29, 0, 0, 0, 0, location)},
+		{"0 0 0 L * ?", time.Date(2026, 10, 31, 0, 0, 0, 0, location)},
+		{"0 0 0 L-2 * ?", time.Date(2026, 10, 29, 0, 0, 0, 0, location)},
+		{"0 0 0 LW * ?", time.Date(2026, 10, 30, 0, 0, 0, 0, location)},
+		{"0 0 0 1W 11 ?", time.Date(2026, 11, 2, 0, 0, 0, 0, location)},
+		{"0 0 0 21W 11 ?", time.Date(2026, 11, 20, 0, 0, 0, 0, location)},
+		{"0 0 0 ? * 6L", time.Date(2026, 10, 30, 0, 0, 0, 0, location)},
+		{"0 0 0 ? * FRI#3", time.Date(2026, 11, 20, 0, 0, 0, 0, location)},
+		{"0 0 0 ? * 2#5", time.Date(2026, 11, 30, 0, 0, 0, 0, location)},
+	}
+	for _, c := range cases {
+		schedule, err := Parse(c.expression)
+		if err != nil {
+			t.Fatalf("parse %q failed, err: %v", c.expression, err)
+		}
+		if next := schedule.Next(from); !next.Equal(c.expected) {
+			t.Errorf("next of %q, expected: %s, actual: %s", c.expression, c.expected, next)
+		}
+	}
+}
+
+// TestNextDaylightSaving 测试夏令时回拨时下一次触发时间一定晚于指定时间，重复的墙上时间只匹配第一次出现
+func TestNextDaylightSaving(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("load location failed, err: %v", err)
+	}
+	schedule, err := Parse("0 30 * ? * *")
+	if err != nil {
+		t.Fatalf("parse failed, err: %v", err)
+	}
+
+	// 2026-11-01 02:00 EDT回拨到01:00 EST，从第二次出现的01:10开始计算时下一次为01:30 EST，不会回到01:30 EDT
+	firstPass := time.Date(2026, 11, 1, 0, 50, 0, 0, location)
+	secondPass := firstPass.Add(time.Hour + 20*time.Minute)
+	if hour, minute, _ := secondPass.Clock(); hour != 1 || minute != 10 {
+		t.Fatalf("unexpected second pass: %v", secondPass)
+	}
+	if next := schedule.Next(secondPass); !next.After(secondPass) || next.Sub(secondPass) != 20*time.Minute {
+		t.Errorf("next should be 01:30 EST, from: %v, next: %v", secondPass, next)
+	}
+
+	from := firstPass
+	var fires []time.Time
+	for _, next := range schedule.NextFireTimes(from, 4) {
+		if !next.After(from) {
+			t.Fatalf("next fire time %v is not after %v", next, from)
+		}
+		from = next
+		fires = append(fires, next)
+	}
+	for i, expected := range []time.Duration{40 * time.Minute, 160 * time.Minute, 220 * time.Minute, 280 * time.Minute} {
+		if fires[i].Sub(firstPass) != expected {
+			t.Errorf("fire time %d should be %v after %v, got: %v", i, expected, firstPass, fires[i])
+		}
+	}
+}
+
+// TestParseError 测试非法表达式
+func TestParseError(t *testing.T) {
+	for _, expression := range []string{"", "* * * * *", "60 * * * * ?", "* * * ? * ?", "* * * * 13 ?", "*/0 * * * * ?", "* * 5-1 * * ?", "* * * * * MONDAY", "0 0 0 15 * MON", "0 0 0 * * MON", "0 0 0 * * *", "0 0 0 L,1 * ?", "0 0 0 32W * ?", "0 0 0 ? * 2#6", "0 0 0 L-31 * ?"} {
+		if _, err := Parse(expression); err == nil {
+			t.Errorf("parse %q should fail", expression)
+		}
+	}
+}
+
+// TestNextFireTimes 测试获取多次触发时间
+func TestNextFireTimes(t *testing.T) {
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("load location failed, err: %v", err)
+	}
+	times, err := NextFireTimes("0 0 9 ? * MON-FRI", 5, location)
+	if err != nil {
+		t.Fatalf("next fire times failed, err: %v", err)
+	}
+	if len(times) != 5 {
+		t.Fatalf("expected 5 fire times, actual: %v", times)
+	}
+	for i, fireTime := range times {
+		if fireTime.Location() != location || fireTime.Hour() != 9 || fireTime.Weekday() == time.Saturday || fireTime.Weekday() == time.Sunday {
+			t.Errorf("unexpected fire time: %s", fireTime)
+		}
+		if i > 0 && !fireTime.After(times[i-1]) {
+			t.Errorf("fire times not ascending: %v", times)
+		}
+	}
+
+	if _, err = NextFireTimes("0 0 9 ? * MON-FRI 1969", 1, nil); err == nil {
+		t.Errorf("year out of range should fail")
+	}
+	if times, _ = NextFireTimes("0 0 0 1 1 ? 2020", 3, nil); len(times) != 0 {
+		t.Errorf("expression in the past should have no fire times, actual: %v", times)
+	}
+}
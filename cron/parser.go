@@ -0,0 +1,246 @@
+package cron
+
+// Created in 2026-10-18 14:10.
+// @author Horace
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// field 表达式字段定义
+type field struct {
+	// name 字段名称，用于错误提示
+	name string
+	// min 最小值
+	min int
+	// max 最大值
+	max int
+	// names 字段值的别名，例如 JAN、MON
+	names map[string]int
+}
+
+var (
+	secondField     = field{name: "second", min: 0, max: 59}
+	minuteField     = field{name: "minute", min: 0, max: 59}
+	hourField       = field{name: "hour", min: 0, max: 23}
+	dayOfMonthField = field{name: "day-of-month", min: 1, max: 31}
+	monthField      = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	// dayOfWeekField 与Quartz保持一致，1表示周日，7表示周六
+	dayOfWeekField = field{name: "day-of-week", min: 1, max: 7, names: map[string]int{
+		"SUN": 1, "MON": 2, "TUE": 3, "WED": 4, "THU": 5, "FRI": 6, "SAT": 7,
+	}}
+	yearField = field{name: "year", min: 1970, max: 2099}
+)
+
+// Parse 解析Quartz风格的CRON表达式，格式为：秒 分 时 日 月 周 [年]
+func Parse(expression string) (*Schedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 6 && len(fields) != 7 {
+		return nil, fmt.Errorf("invalid cron expression %q, expected 6 or 7 fields (second minute hour day-of-month month day-of-week [year]), got %d", expression, len(fields))
+	}
+
+	schedule := &Schedule{expression: expression}
+	var err error
+	if schedule.seconds, err = parseField(fields[0], secondField); err != nil {
+		return nil, wrapError(expression, err)
+	}
+	if schedule.minutes, err = parseField(fields[1], minuteField); err != nil {
+		return nil, wrapError(expression, err)
+	}
+	if schedule.hours, err = parseField(fields[2], hourField); err != nil {
+		return nil, wrapError(expression, err)
+	}
+	if fields[3] == "?" {
+		schedule.dayOfMonthAny = true
+	} else if err = parseDayOfMonth(fields[3], schedule); err != nil {
+		return nil, wrapError(expression, err)
+	}
+	if schedule.months, err = parseField(fields[4], monthField); err != nil {
+		return nil, wrapError(expression, err)
+	}
+	if fields[5] == "?" {
+		schedule.dayOfWeekAny = true
+	} else if err = parseDayOfWeek(fields[5], schedule); err != nil {
+		return nil, wrapError(expression, err)
+	}
+	if schedule.dayOfMonthAny && schedule.dayOfWeekAny {
+		return nil, wrapError(expression, fmt.Errorf("'?' can not be used in both day-of-month and day-of-week"))
+	}
+	// 与Quartz保持一致，日和周必须有一个使用'?'，例如0 0 0 * * MON需要写成0 0 0 ? * MON
+	if !schedule.dayOfMonthAny && !schedule.dayOfWeekAny {
+		return nil, wrapError(expression, fmt.Errorf("specifying both a day-of-month and a day-of-week is not supported, use '?' in one of them"))
+	}
+	if len(fields) == 7 {
+		if schedule.years, err = parseField(fields[6], yearField); err != nil {
+			return nil, wrapError(expression, err)
+		}
+	}
+	return schedule, nil
+}
+
+// Validate 校验CRON表达式是否合法
+func Validate(expression string) error {
+	_, err := Parse(expression)
+	return err
+}
+
+// parseDayOfMonth 解析日字段，除通用语法外，支持 L（最后一天）、L-n（倒数第n+1天）、LW（最后一个工作日）、nW（离n号最近的工作日）
+func parseDayOfMonth(value string, schedule *Schedule) error {
+	upper := strings.ToUpper(value)
+	if !strings.ContainsAny(upper, "LW") {
+		var err error
+		schedule.daysOfMonth, err = parseField(value, dayOfMonthField)
+		return err
+	}
+	if strings.Contains(upper, ",") {
+		return fmt.Errorf("day-of-month field %q, 'L' and 'W' can not be used in a list", value)
+	}
+
+	schedule.daysOfMonth = make(map[int]bool)
+	switch {
+	case upper == "L":
+		schedule.lastDayOfMonth = true
+	case upper == "LW":
+		schedule.lastWeekdayOfMonth = true
+	case strings.HasPrefix(upper, "L-"):
+		offset, err := strconv.Atoi(upper[2:])
+		if err != nil || offset < 0 || offset > 30 {
+			return fmt.Errorf("day-of-month field has an invalid offset %q, expected L-n where n in [0, 30]", value)
+		}
+		schedule.lastDayOfMonth = true
+		schedule.lastDayOffset = offset
+	case strings.HasSuffix(upper, "W"):
+		day, err := parseValue(upper[:len(upper)-1], dayOfMonthField)
+		if err != nil {
+			return fmt.Errorf("day-of-month field %q, 'W' must follow a single day: %w", value, err)
+		}
+		schedule.nearestWeekday = day
+	default:
+		return fmt.Errorf("day-of-month field has an invalid value %q", value)
+	}
+	return nil
+}
+
+// parseDayOfWeek 解析周字段，除通用语法外，支持 L（周六）、nL（本月最后一个周n）、n#k（本月第k个周n）
+func parseDayOfWeek(value string, schedule *Schedule) error {
+	upper := strings.ToUpper(value)
+	if !strings.ContainsAny(upper, "L#") {
+		var err error
+		schedule.daysOfWeek, err = parseField(value, dayOfWeekField)
+		return err
+	}
+	if strings.Contains(upper, ",") {
+		return fmt.Errorf("day-of-week field %q, 'L' and '#' can not be used in a list", value)
+	}
+
+	schedule.daysOfWeek = make(map[int]bool)
+	switch {
+	case upper == "L":
+		schedule.daysOfWeek[dayOfWeekField.max] = true
+	case strings.HasSuffix(upper, "L"):
+		day, err := parseValue(upper[:len(upper)-1], dayOfWeekField)
+		if err != nil {
+			return fmt.Errorf("day-of-week field %q, 'L' must follow a single day: %w", value, err)
+		}
+		schedule.lastDayOfWeek = day
+	case strings.Contains(upper, "#"):
+		parts := strings.SplitN(upper, "#", 2)
+		day, err := parseValue(parts[0], dayOfWeekField)
+		if err != nil {
+			return fmt.Errorf("day-of-week field %q, '#' must follow a single day: %w", value, err)
+		}
+		nth, err := strconv.Atoi(parts[1])
+		if err != nil || nth < 1 || nth > 5 {
+			return fmt.Errorf("day-of-week field has an invalid value %q, expected n#k where k in [1, 5]", value)
+		}
+		schedule.nthDayOfWeek = day
+		schedule.nthDayOfWeekIndex = nth
+	default:
+		return fmt.Errorf("day-of-week field has an invalid value %q", value)
+	}
+	return nil
+}
+
+// parseField 解析单个字段，返回字段允许的取值集合
+func parseField(value string, field field) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(value, ",") {
+		if err := parsePart(part, field, values); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// parsePart 解析字段中以逗号分隔的一部分，支持 *、a、a-b、a/n、a-b/n、*/n
+func parsePart(part string, field field, values map[int]bool) error {
+	if part == "" {
+		return fmt.Errorf("%s field has an empty value", field.name)
+	}
+
+	rangePart, step := part, 1
+	if index := strings.Index(part, "/"); index >= 0 {
+		rangePart = part[:index]
+		var err error
+		step, err = strconv.Atoi(part[index+1:])
+		if err != nil || step <= 0 {
+			return fmt.Errorf("%s field has an invalid step %q", field.name, part[index+1:])
+		}
+	}
+
+	start, end := field.min, field.max
+	switch {
+	case rangePart == "*":
+	case strings.Contains(rangePart, "-"):
+		bounds := strings.SplitN(rangePart, "-", 2)
+		var err error
+		if start, err = parseValue(bounds[0], field); err != nil {
+			return err
+		}
+		if end, err = parseValue(bounds[1], field); err != nil {
+			return err
+		}
+		if start > end {
+			return fmt.Errorf("%s field has an invalid range %q, start is greater than end", field.name, rangePart)
+		}
+	default:
+		var err error
+		if start, err = parseValue(rangePart, field); err != nil {
+			return err
+		}
+		// 没有步长时只表示单个值，有步长时表示从该值开始到最大值
+		if step == 1 && !strings.Contains(part, "/") {
+			end = start
+		}
+	}
+
+	for i := start; i <= end; i += step {
+		values[i] = true
+	}
+	return nil
+}
+
+// parseValue 解析单个值，支持数字和别名
+func parseValue(value string, field field) (int, error) {
+	if number, ok := field.names[strings.ToUpper(value)]; ok {
+		return number, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s field has an invalid value %q", field.name, value)
+	}
+	if number < field.min || number > field.max {
+		return 0, fmt.Errorf("%s field value %d out of range [%d, %d]", field.name, number, field.min, field.max)
+	}
+	return number, nil
+}
+
+// wrapError 包装错误信息，带上完整的表达式
+func wrapError(expression string, err error) error {
+	return fmt.Errorf("invalid cron expression %q: %w", expression, err)
+}
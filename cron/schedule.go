@@ -0,0 +1,193 @@
+package cron
+
+// Created in 2026-10-18 14:10.
+// @author Horace
+
+import (
+	"time"
+)
+
+// Schedule 解析后的CRON表达式
+type Schedule struct {
+	// expression 原始表达式
+	expression string
+	// seconds 秒的取值集合
+	seconds map[int]bool
+	// minutes 分钟的取值集合
+	minutes map[int]bool
+	// hours 小时的取值集合
+	hours map[int]bool
+	// daysOfMonth 日的取值集合
+	daysOfMonth map[int]bool
+	// dayOfMonthAny 日是否不限制，使用 ? 表示，与dayOfWeekAny有且只有一个为true
+	dayOfMonthAny bool
+	// lastDayOfMonth 是否为月的最后一天，L 或者 L-n
+	lastDayOfMonth bool
+	// lastDayOffset 距离月最后一天的偏移量，L-n 中的n
+	lastDayOffset int
+	// lastWeekdayOfMonth 是否为月的最后一个工作日，LW
+	lastWeekdayOfMonth bool
+	// nearestWeekday 离指定日期最近的工作日，nW 中的n，0表示未设置
+	nearestWeekday int
+	// months 月的取值集合
+	months map[int]bool
+	// daysOfWeek 周的取值集合，1表示周日
+	daysOfWeek map[int]bool
+	// dayOfWeekAny 周是否不限制，使用 ? 表示，与dayOfMonthAny有且只有一个为true
+	dayOfWeekAny bool
+	// lastDayOfWeek 本月最后一个周n，nL 中的n，0表示未设置
+	lastDayOfWeek int
+	// nthDayOfWeek 本月第k个周n，n#k 中的n，0表示未设置
+	nthDayOfWeek int
+	// nthDayOfWeekIndex 本月第k个周n，n#k 中的k
+	nthDayOfWeekIndex int
+	// years 年的取值集合，为空表示不限制
+	years map[int]bool
+}
+
+// String 返回原始表达式
+func (schedule *Schedule) String() string {
+	return schedule.expression
+}
+
+// Next 获取指定时间之后的下一次触发时间，使用t的时区计算，没有下一次触发时间时返回零值。
+// 返回值一定晚于t，夏令时回拨时重复的墙上时间只匹配第一次出现，t位于第二次出现的时段中时从t之后继续匹配
+func (schedule *Schedule) Next(t time.Time) time.Time {
+	location := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+
+	for t.Year() <= yearField.max {
+		// 年
+		if schedule.years != nil && !schedule.years[t.Year()] {
+			t = time.Date(t.Year()+1, time.January, 1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		// 月
+		if !schedule.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		// 日
+		if !schedule.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+
+		// 时
+		if !schedule.hours[t.Hour()] {
+			t = later(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location))
+			continue
+		}
+
+		// 分
+		if !schedule.minutes[t.Minute()] {
+			t = later(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, location))
+			continue
+		}
+
+		// 秒
+		if !schedule.seconds[t.Second()] {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// later 按照墙上时间构造的next在夏令时回拨的重复时段中可能早于t，此时取相同墙上时间的后一次出现，保证时间只向后推进
+func later(t time.Time, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	_, nextOffset := next.Zone()
+	_, offset := t.Zone()
+	if next = next.Add(time.Duration(nextOffset-offset) * time.Second); next.After(t) {
+		return next
+	}
+	return t.Add(time.Second)
+}
+
+// NextFireTimes 获取指定时间之后的n次触发时间
+func (schedule *Schedule) NextFireTimes(from time.Time, n int) []time.Time {
+	times := make([]time.Time, 0, n)
+	for len(times) < n {
+		from = schedule.Next(from)
+		if from.IsZero() {
+			break
+		}
+		times = append(times, from)
+	}
+	return times
+}
+
+// NextFireTimes 解析CRON表达式，获取从当前时间开始的n次触发时间，location为空时使用本地时区
+func NextFireTimes(expression string, n int, location *time.Location) ([]time.Time, error) {
+	schedule, err := Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		location = time.Local
+	}
+	return schedule.NextFireTimes(time.Now().In(location), n), nil
+}
+
+// matchDay 判断日期是否匹配日和周的配置，解析时已经保证日和周有且只有一个为 ?，只需要匹配另一个
+func (schedule *Schedule) matchDay(t time.Time) bool {
+	if schedule.dayOfMonthAny {
+		return schedule.matchDayOfWeek(t)
+	}
+	return schedule.matchDayOfMonth(t)
+}
+
+// matchDayOfMonth 判断日期是否匹配日的配置
+func (schedule *Schedule) matchDayOfMonth(t time.Time) bool {
+	day := t.Day()
+	lastDay := daysIn(t)
+	switch {
+	case schedule.lastDayOfMonth:
+		return day == lastDay-schedule.lastDayOffset
+	case schedule.lastWeekdayOfMonth:
+		return day == nearestWeekday(t, lastDay, lastDay)
+	case schedule.nearestWeekday > 0:
+		return schedule.nearestWeekday <= lastDay && day == nearestWeekday(t, schedule.nearestWeekday, lastDay)
+	}
+	return schedule.daysOfMonth[day]
+}
+
+// matchDayOfWeek 判断日期是否匹配周的配置
+func (schedule *Schedule) matchDayOfWeek(t time.Time) bool {
+	dayOfWeek := int(t.Weekday()) + 1
+	switch {
+	case schedule.lastDayOfWeek > 0:
+		return dayOfWeek == schedule.lastDayOfWeek && t.Day()+7 > daysIn(t)
+	case schedule.nthDayOfWeek > 0:
+		return dayOfWeek == schedule.nthDayOfWeek && (t.Day()-1)/7+1 == schedule.nthDayOfWeekIndex
+	}
+	return schedule.daysOfWeek[dayOfWeek]
+}
+
+// daysIn 获取日期所在月的天数
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// nearestWeekday 获取本月中离指定日期最近的工作日，不会跨月
+func nearestWeekday(t time.Time, day int, lastDay int) int {
+	switch time.Date(t.Year(), t.Month(), day, 0, 0, 0, 0, t.Location()).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2
+		}
+		return day - 1
+	case time.Sunday:
+		if day == lastDay {
+			return day - 2
+		}
+		return day + 1
+	}
+	return day
+}
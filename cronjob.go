@@ -4,20 +4,31 @@ package cronjob
 // @author Horace
 
 import (
+	stdContext "context"
+	"database/sql"
+	"errors"
+	"fmt"
 	logger "github.com/cihub/seelog"
 	"github.com/horacedh/cronjob-executor/bean"
 	"github.com/horacedh/cronjob-executor/context"
+	"github.com/horacedh/cronjob-executor/handlers"
 	"github.com/horacedh/cronjob-executor/httpclients"
 	_ "github.com/horacedh/cronjob-executor/loggers"
 	"github.com/horacedh/cronjob-executor/services"
 	"github.com/horacedh/cronjob-executor/task"
 	"github.com/horacedh/cronjob-executor/utils"
 	"github.com/horacedh/cronjob-executor/webserver"
+	"github.com/horacedh/cronjob-executor/workflow"
 	"reflect"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// shutdownFlushTimeout 停机超时后，发送任务结果和关闭Http服务的最长等待时间
+const shutdownFlushTimeout = time.Second * 5
+
 // 单例模式
 var (
 	executorClient     ExecutorClient
@@ -26,10 +37,34 @@ var (
 
 // ExecutorClient 接口
 type ExecutorClient interface {
-	// AddTask 添加任务
+	// AddTask 添加任务，参数不合法时panic
 	AddTask(handler task.TaskHandler, options bean.TaskOptions)
+	// TryAddTask 添加任务，参数不合法时返回错误
+	TryAddTask(handler task.TaskHandler, options bean.TaskOptions) error
+	// AddTasks 批量添加任务，一次性返回所有任务的全部问题，有任何问题时不会添加任务
+	AddTasks(definitions ...TaskDefinition) error
+	// UpdateTask 修改已添加任务的配置，启动后立即同步给调度器
+	UpdateTask(handler task.TaskHandler, options bean.TaskOptions) error
+	// RemoveTask 移除任务并从调度器中注销，name可以是任务方法（包路径+方法名）或者任务名称，正在执行的任务会继续执行完成
+	RemoveTask(name string) error
+	// ReloadTasks 使用新的任务集合替换全部任务，新增、修改、移除的任务一次性生效，有任何问题时不会修改任务
+	ReloadTasks(definitions ...TaskDefinition) error
+	// AddWorkflow 添加工作流，工作流作为一个任务注册到调度器，按照依赖顺序执行节点，节点引用的任务需要先添加
+	AddWorkflow(workflow *workflow.Workflow, options bean.TaskOptions) error
+	// WatchConfig 按照固定频率检查配置文件，文件变化后重新加载任务，执行器配置的变化需要重启才能生效
+	WatchConfig(path string, handlers map[string]task.TaskHandler, interval time.Duration)
+	// Drain 开始摘流，从调度器注销执行器并拒绝新的调度请求，队列中和正在执行的任务继续执行完成，进程保持存活，最多等待wait时间，返回是否摘流完成
+	Drain(wait time.Duration) bool
+	// Resume 结束摘流，重新注册执行器和全部任务
+	Resume()
+	// RegisterDB 注册数据库，内置的SQL任务按照名称使用，需要在任务执行之前调用
+	RegisterDB(name string, db *sql.DB)
+	// SetCheckpointStore 使用自定义的检查点存储，替换配置中的本地文件或者OpenApi存储，需要在Start和RunNow之前调用
+	SetCheckpointStore(store task.CheckpointStore)
 	// Start 启动执行器客户端
 	Start()
+	// RunNow 在本执行器上立即执行任务，用于本地调试，name可以是任务方法（包路径+方法名）或者任务名称，同步返回任务处理结果
+	RunNow(name string, options bean.RunNowOptions) (*task.HandlerResult, error)
 	// Stop 停止执行器客户端
 	stop()
 }
@@ -38,76 +73,327 @@ type ExecutorClient interface {
 type executorClientImpl struct {
 	// options 配置参数
 	options bean.ExecutorOptions
-	// handlers 任务处理方法集合，key为包路径+方法名，value为方法的反射值
-	handlers map[string]*reflect.Value
-	// taskOptions 任务配置集合，key为包路径+方法名，value为任务配置
-	taskOptions map[string]*bean.TaskOptions
+	// registry 任务注册表，保存任务处理方法和任务配置，运行时可以修改
+	registry services.TaskRegistry
+	// mu 互斥锁，保证任务的校验和修改是原子的
+	mu sync.Mutex
+	// registerMu 互斥锁，保证任务按照修改顺序注册到调度器
+	registerMu sync.Mutex
+	// pushVersion 任务推送的版本号，每次推送加一，已经有更新的推送时旧的推送不再注册
+	pushVersion atomic.Int64
+	// pendingRemoved 等待从调度器注销的任务方法，通过mu读写
+	pendingRemoved []string
+	// started 是否已经启动
+	started atomic.Bool
+	// registering 是否正在检查注册状态
+	registering atomic.Bool
+	// reconcileOnce 启动后只检查一次孤儿任务
+	reconcileOnce sync.Once
+	// checkpoints 检查点存储，为空时按照配置创建，通过mu读写
+	checkpoints task.CheckpointStore
 }
 
-// AddTask 添加任务处理器
+// AddTask 添加任务处理器，参数不合法时panic
 func (client *executorClientImpl) AddTask(handler task.TaskHandler, options bean.TaskOptions) {
-	// 检查参数
-	if options.Cron == "" {
-		panic("invalid cronjob options, please set cron.")
+	if err := client.TryAddTask(handler, options); err != nil {
+		panic(err.Error())
+	}
+}
+
+// TryAddTask 添加任务处理器，参数不合法时返回错误
+func (client *executorClientImpl) TryAddTask(handler task.TaskHandler, options bean.TaskOptions) error {
+	return client.AddTasks(TaskDefinition{Handler: handler, Options: options})
+}
+
+// AddTasks 批量添加任务处理器，一次性校验所有任务并返回全部问题，有任何问题时不会添加任务
+func (client *executorClientImpl) AddTasks(definitions ...TaskDefinition) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	handlers, taskOptions, err := client.prepareTasks(client.registry.Options(), definitions...)
+	if err != nil {
+		return err
+	}
+	for key, handleMethod := range handlers {
+		client.registry.Put(key, handleMethod, taskOptions[key])
+	}
+	if client.started.Load() {
+		logger.Infof("add tasks, methods:%v", sortedKeys(taskOptions))
+	}
+	client.pushTasks()
+	return nil
+}
+
+// UpdateTask 修改已添加任务的配置
+func (client *executorClientImpl) UpdateTask(handler task.TaskHandler, options bean.TaskOptions) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	key, _, err := resolveHandler(client.options.AppName, handler)
+	if err != nil {
+		return fmt.Errorf("invalid task options: %w", err)
 	}
-	if options.Name == "" {
-		panic("invalid task options, please set name.")
+	existing := client.registry.Options()
+	if existing[key] == nil {
+		return errors.New("task not found: " + key)
 	}
+	delete(existing, key)
 
-	if options.RouterStrategy == 0 {
-		options.RouterStrategy = bean.RANDOM
+	handlers, taskOptions, err := client.prepareTasks(existing, TaskDefinition{Handler: handler, Options: options})
+	if err != nil {
+		return err
 	}
-	if options.ExpiredStrategy == 0 {
-		options.ExpiredStrategy = bean.ExpiredExecute
+	client.registry.Put(key, handlers[key], taskOptions[key])
+	logger.Infof("update task, method:%s, options:%s", key, utils.ToJsonString(taskOptions[key]))
+	client.pushTasks()
+	return nil
+}
+
+// RemoveTask 移除任务
+func (client *executorClientImpl) RemoveTask(name string) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	method, _ := client.registry.Find(name)
+	if method == "" || !client.registry.Remove(method) {
+		return errors.New("task not found: " + name)
 	}
-	if options.ExpireTime == 0 {
-		options.ExpireTime = 3 * 60 * 1000
+	logger.Infof("remove task, method:%s", method)
+	client.pushTasks(method)
+	return nil
+}
+
+// ReloadTasks 使用新的任务集合替换全部任务
+func (client *executorClientImpl) ReloadTasks(definitions ...TaskDefinition) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	handlers, taskOptions, err := client.prepareTasks(map[string]*bean.TaskOptions{}, definitions...)
+	if err != nil {
+		return err
+	}
+
+	var added, updated, removed []string
+	existing := client.registry.Options()
+	for key, options := range taskOptions {
+		if current := existing[key]; current == nil {
+			added = append(added, key)
+		} else if !sameTaskOptions(current, options) {
+			updated = append(updated, key)
+		} else {
+			continue
+		}
+		client.registry.Put(key, handlers[key], options)
 	}
-	if options.FailureStrategy == 0 {
-		options.FailureStrategy = bean.FailureRetry
+	for key := range existing {
+		if taskOptions[key] == nil {
+			client.registry.Remove(key)
+			removed = append(removed, key)
+		}
+	}
+	if len(added)+len(updated)+len(removed) == 0 {
+		return nil
+	}
+
+	sort.Strings(added)
+	sort.Strings(updated)
+	sort.Strings(removed)
+	logger.Infof("reload tasks, added:%v, updated:%v, removed:%v", added, updated, removed)
+	client.pushTasks(removed...)
+	return nil
+}
+
+// prepareTasks 校验任务并设置默认值，existing为校验重复时使用的已有任务，有任何问题时返回所有问题
+func (client *executorClientImpl) prepareTasks(existing map[string]*bean.TaskOptions, definitions ...TaskDefinition) (map[string]*reflect.Value, map[string]*bean.TaskOptions, error) {
+	names := make(map[string]string)
+	for key, options := range existing {
+		names[options.Name] = key
+	}
+
+	var errs []error
+	handlers := make(map[string]*reflect.Value)
+	taskOptions := make(map[string]*bean.TaskOptions)
+	for _, definition := range definitions {
+		options := definition.Options
+		setDefaultTaskOptions(&options)
+
+		taskErrs := validateTaskOptions(&options)
+		if len(options.OnFailure) > 0 && options.FailureStrategy == bean.FailureRetry && !client.options.Standalone {
+			taskErrs = append(taskErrs, errors.New("onFailure requires failureStrategy discard, the scheduler does not send the retry count to tell the final failure"))
+		}
+		key, handleMethod, err := resolveHandler(client.options.AppName, definition.Handler)
+		if err != nil {
+			taskErrs = append(taskErrs, err)
+		} else if existing[key] != nil || taskOptions[key] != nil {
+			taskErrs = append(taskErrs, fmt.Errorf("duplicate method %s", key))
+		}
+		if existingKey, ok := names[options.Name]; ok && options.Name != "" {
+			taskErrs = append(taskErrs, fmt.Errorf("duplicate name, already used by method %s", existingKey))
+		}
+		if len(taskErrs) > 0 {
+			errs = append(errs, fmt.Errorf("task %q: %w", options.Name, errors.Join(taskErrs...)))
+			continue
+		}
+
+		names[options.Name] = key
+		handlers[key] = handleMethod
+		taskOptions[key] = &options
+	}
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("invalid task options: %w", errors.Join(errs...))
+	}
+
+	// 检查添加后的全部任务的后续任务是否形成环
+	tasks := make(map[string]*bean.TaskOptions, len(existing)+len(taskOptions))
+	for key, options := range existing {
+		tasks[key] = options
+	}
+	for key, options := range taskOptions {
+		tasks[key] = options
+	}
+	if err := validateChains(tasks); err != nil {
+		return nil, nil, fmt.Errorf("invalid task options: %w", err)
+	}
+	return handlers, taskOptions, nil
+}
+
+// pushTasks 启动后任务发生变化时，立即从调度器注销已移除的任务，并注册当前的全部任务，不等待下一次定时注册，调用方需要持有mu
+func (client *executorClientImpl) pushTasks(removed ...string) {
+	if !client.started.Load() || client.options.Standalone || context.Shutdown.Load() || context.Draining.Load() {
+		return
 	}
-	if options.MaxRetryCount == 0 {
-		options.MaxRetryCount = 5
+	client.pendingRemoved = append(client.pendingRemoved, removed...)
+	go client.registerTasks(client.pushVersion.Add(1))
+}
+
+// registerTasks 注销已移除的任务，任务集合发生变化时将当前的全部任务注册到调度器。
+// 等待上一次推送期间有更新的推送时跳过，由最新的推送注销累计移除的任务并注册最新的任务集合
+func (client *executorClientImpl) registerTasks(version int64) {
+	client.registerMu.Lock()
+	defer client.registerMu.Unlock()
+	if client.pushVersion.Load() != version {
+		return
 	}
-	if options.FailureRetryInterval == 0 {
-		options.FailureRetryInterval = 5000
+
+	client.mu.Lock()
+	removed := client.pendingRemoved
+	client.pendingRemoved = nil
+	client.mu.Unlock()
+	services.GetRegisterService().UnregisterTask(client.options, removed)
+	services.GetRegisterService().SyncTask(client.options, client.registry.Options(), false)
+}
+
+// register 检查注册状态，执行器未注册或者调度器要求重新注册时，重新注册执行器和全部任务，否则只在任务集合变化时注册任务
+func (client *executorClientImpl) register(address string) {
+	// 注册失败时会一直重试，避免上一次检查还没结束时重复注册，摘流时不注册
+	if context.Shutdown.Load() || context.Draining.Load() || !client.registering.CompareAndSwap(false, true) {
+		return
 	}
-	if options.Timeout == 0 {
-		options.Timeout = 10000
+	defer client.registering.Store(false)
+
+	registerService := services.GetRegisterService()
+	force := registerService.SyncExecutor(client.options, address)
+
+	client.registerMu.Lock()
+	registerService.SyncTask(client.options, client.registry.Options(), force)
+	client.registerMu.Unlock()
+
+	// 首次注册后检查调度器中的孤儿任务
+	client.reconcileOnce.Do(func() {
+		registerService.ReconcileTask(client.options, client.registry.Options())
+	})
+}
+
+// RunNow 在本执行器上立即执行任务，不经过调度器，执行类型为手动执行
+func (client *executorClientImpl) RunNow(name string, options bean.RunNowOptions) (*task.HandlerResult, error) {
+	dispatcherService := services.InitDispatcherService(client.options, client.registry, client.checkpointStore())
+	return dispatcherService.RunNow(webserver.GetHttpServer().GetAddress(), name, options)
+}
+
+// Drain 开始摘流，wait大于0时等待队列中和正在执行的任务完成，并且任务结果发送完成
+func (client *executorClientImpl) Drain(wait time.Duration) bool {
+	drainService := services.GetDrainService()
+	drainService.Drain(webserver.GetHttpServer().GetAddress())
+	if wait <= 0 {
+		return drainService.IsDrained()
 	}
+	return drainService.WaitDrained(wait)
+}
 
-	// 生成唯一key（包路径+方法名）
-	key := client.options.AppName + "/" + reflect.TypeOf(handler).String() + ".Handle"
+// Resume 结束摘流
+func (client *executorClientImpl) Resume() {
+	services.GetDrainService().Resume()
+}
 
-	// 缓存Handle方法的反射值
-	handleMethod := reflect.ValueOf(handler).MethodByName("Handle")
-	if !handleMethod.IsValid() {
-		panic("Handle method can not be found")
+// RegisterDB 注册数据库
+func (client *executorClientImpl) RegisterDB(name string, db *sql.DB) {
+	handlers.RegisterDB(name, db)
+}
+
+// SetCheckpointStore 使用自定义的检查点存储
+func (client *executorClientImpl) SetCheckpointStore(store task.CheckpointStore) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.checkpoints = store
+}
+
+// checkpointStore 获取检查点存储，没有设置自定义存储时按照配置创建，本地文件存储在第一次使用时创建，创建失败时加载和保存检查点返回错误
+func (client *executorClientImpl) checkpointStore() task.CheckpointStore {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.checkpoints != nil {
+		return client.checkpoints
+	}
+	if client.options.CheckpointStore == bean.CheckpointOpenApi {
+		client.checkpoints = services.NewOpenApiCheckpointStore(client.options)
+		return client.checkpoints
 	}
 
-	client.handlers[key] = &handleMethod
-	client.taskOptions[key] = &options
+	// 本地文件存储在处理方法第一次使用检查点时才创建目录
+	dir := client.options.CheckpointDir
+	client.checkpoints = services.NewLazyCheckpointStore(func() (task.CheckpointStore, error) {
+		store, err := services.NewFileCheckpointStore(dir)
+		if err != nil {
+			logger.Errorf("create checkpoint store failed, dir:%s, err:%v", dir, err)
+		}
+		return store, err
+	})
+	return client.checkpoints
 }
 
-// Stop 停止
+// Stop 停止，最多等待停机超时时间加上发送任务结果的时间
 func (client *executorClientImpl) stop() {
+	deadline := time.Now().Add(time.Duration(client.options.ShutdownTimeout) * time.Millisecond)
+	context.ShutdownDeadline.Store(deadline.UnixMilli())
 	context.Shutdown.Store(true)
 
 	// 向调度器发送下线的请求
 	services.GetRegisterService().Unregister(webserver.GetHttpServer().GetAddress())
 
 	// 等待调度器处理完成，等待结果发送完成
-	context.WaitGroup.Wait()
+	if !utils.WaitTimeout(&context.WaitGroup, time.Until(deadline)+shutdownFlushTimeout) {
+		logger.Warnf("cron-job executor shutdown timeout, unsent results:%d", services.GetResultSendService().GetPendingCount())
+	}
+
+	// 关闭Http服务，等待正在处理的请求完成
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), shutdownFlushTimeout)
+	defer cancel()
+	if err := webserver.GetHttpServer().Shutdown(ctx); err != nil {
+		logger.Warnf("cron-job web server shutdown failed, err:%v", err)
+	}
 }
 
 // Start 启动
 func (client *executorClientImpl) Start() {
-	// 注册关闭钩子
+	// 注册关闭钩子，以及摘流和恢复的信号
 	utils.SignalNotify(client.stop)
+	utils.SignalNotifyDrain(func() {
+		client.Drain(0)
+	}, client.Resume)
 
 	// 初始化HttpClient
 	httpclients.Init(httpclients.Options{
-		Timeout: time.Second * 5,
+		Timeout: httpclients.DefaultTimeout,
 		SignKey: client.options.SignKey,
 	})
 
@@ -120,26 +406,29 @@ func (client *executorClientImpl) Start() {
 		time.Sleep(time.Millisecond * 200)
 	}
 
-	// 每30秒注册执行器和任务
-	utils.GetScheduler().ScheduleAtFixedRate(time.Second*30, true, func() {
-		if context.Shutdown.Load() {
-			return
-		}
-
-		// 注册执行器和任务
-		services.GetRegisterService().RegisterExecutor(client.options, httpServer.GetAddress())
+	// 开始调度
+	dispatcherService := services.InitDispatcherService(client.options, client.registry, client.checkpointStore())
 
-		time.Sleep(time.Second)
+	if client.options.Standalone {
+		// 单机模式下由执行器自己生成任务，不需要注册和心跳
+		services.GetStandaloneService().Start(client.options, client.registry)
+	} else {
+		// 按照固定频率检查注册状态，任务集合不变时只通过心跳保持存活
+		registerInterval := time.Duration(client.options.RegisterInterval) * time.Millisecond
+		utils.GetScheduler().ScheduleAtFixedRate(registerInterval, true, func() {
+			client.register(httpServer.GetAddress())
+		})
 
-		// 注册任务
-		services.GetRegisterService().RegisterTask(client.options, client.taskOptions)
-	})
+		// 摘流恢复后立即重新注册，不等待下一次检查
+		services.GetDrainService().OnResume(func() {
+			client.register(httpServer.GetAddress())
+		})
 
-	// 开始心跳，如果执行器未注册成功，则不会开始心跳
-	services.GetHeartbeatService().Start(httpServer.GetAddress())
+		// 开始心跳，如果执行器未注册成功，则不会开始心跳
+		services.GetHeartbeatService().Start(httpServer.GetAddress(), client.options.HeartbeatInterval)
+	}
 
-	// 开始调度
-	dispatcherService := services.InitDispatcherService(client.handlers, client.taskOptions)
+	client.started.Store(true)
 	logger.Infof("start cron-job executor success, options: %s", utils.ToJsonString(client.options))
 	dispatcherService.Start(httpServer.GetAddress())
 
@@ -147,28 +436,57 @@ func (client *executorClientImpl) Start() {
 	select {}
 }
 
-// checkOptions 检查参数
-func checkOptions(options *bean.ExecutorOptions) {
-	if options.Address == "" || options.SignKey == "" || options.Tenant == "" || options.AppName == "" || options.AppDesc == "" {
-		panic("invalid cronjob bean, please set parameters.")
+// NewExecutorClient 获取实例对象，参数不合法时返回错误，不会panic
+func NewExecutorClient(option *bean.ExecutorOptions) (ExecutorClient, error) {
+	if err := ValidateExecutorOptions(option); err != nil {
+		return nil, err
 	}
+	return GetExecutorClient(option), nil
 }
 
-// GetExecutorClient 获取实例对象，需要先调用init方法
+// GetExecutorClient 获取实例对象，需要先调用init方法，参数不合法时panic
 func GetExecutorClient(option *bean.ExecutorOptions) ExecutorClient {
 	executorClientOnce.Do(func() {
 		// 检查参数
-		checkOptions(option)
-		if option.Tag == "" {
-			option.Tag = "common"
+		if err := ValidateExecutorOptions(option); err != nil {
+			panic(err.Error())
 		}
-		services.GetOpenApiService().SetHost(option.Address)
-		executorClient = &executorClientImpl{
-			options:     *option,
-			handlers:    make(map[string]*reflect.Value),
-			taskOptions: make(map[string]*bean.TaskOptions),
+		setDefaultExecutorOptions(option)
+		if option.Standalone {
+			services.UseStandaloneOpenApiService()
 		}
+		services.GetOpenApiService().SetHost(option.Address)
+		executorClient = newExecutorClient(*option)
 		context.SignKey.Store(option.SignKey)
+		context.AdminReadKey.Store(option.AdminReadKey)
 	})
 	return executorClient
 }
+
+// newExecutorClient 创建执行器客户端
+func newExecutorClient(options bean.ExecutorOptions) *executorClientImpl {
+	return &executorClientImpl{
+		options:  options,
+		registry: services.NewTaskRegistry(),
+	}
+}
+
+// sameTaskOptions 比较任务配置是否相同，函数类型的字段只比较函数地址
+func sameTaskOptions(a, b *bean.TaskOptions) bool {
+	if reflect.ValueOf(a.LocalRetryClassifier).Pointer() != reflect.ValueOf(b.LocalRetryClassifier).Pointer() {
+		return false
+	}
+	x, y := *a, *b
+	x.LocalRetryClassifier, y.LocalRetryClassifier = nil, nil
+	return reflect.DeepEqual(x, y)
+}
+
+// sortedKeys 获取排序后的任务方法
+func sortedKeys(taskOptions map[string]*bean.TaskOptions) []string {
+	keys := make([]string, 0, len(taskOptions))
+	for key := range taskOptions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
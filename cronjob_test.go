@@ -1,11 +1,20 @@
 package cronjob
 
 import (
+	"encoding/json"
 	logger "github.com/cihub/seelog"
 	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/httpclients"
+	"github.com/horacedh/cronjob-executor/services"
 	"github.com/horacedh/cronjob-executor/task"
 	"github.com/horacedh/cronjob-executor/utils"
+	"github.com/horacedh/cronjob-executor/webresult"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Created in 2025-03-18 20:44.
@@ -29,6 +38,15 @@ func (d DemoTask1) Handle(params *task.TaskParams) *task.HandlerResult {
 	return task.Success()
 }
 
+type DemoErrorTask struct {
+}
+
+// Handle 返回error的任务处理方法
+func (d DemoErrorTask) Handle(params *task.TaskParams) error {
+	logger.Infof("error task handle, params: %v", utils.ToJsonString(params))
+	return nil
+}
+
 // TestExecutorClient 测试执行器客户端
 func TestExecutorClient(t *testing.T) {
 	client := GetExecutorClient(&bean.ExecutorOptions{
@@ -49,3 +67,82 @@ func TestExecutorClient(t *testing.T) {
 	})
 	client.Start()
 }
+
+// TestPushTasks 测试任务变化时推送到调度器，上一次推送阻塞期间的多次推送只注册最新的任务集合，累计移除的任务一起注销
+func TestPushTasks(t *testing.T) {
+	var mu sync.Mutex
+	var registered [][]string
+	var unregistered []string
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	var first sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/openapi/task/register":
+			first.Do(func() {
+				close(blocked)
+				<-release
+			})
+			var params []bean.TaskRegisterParams
+			_ = json.NewDecoder(request.Body).Decode(&params)
+			var names []string
+			for _, param := range params {
+				names = append(names, param.Name)
+			}
+			mu.Lock()
+			registered = append(registered, names)
+			mu.Unlock()
+		case "/openapi/task/unregister":
+			var params bean.TaskUnregisterParams
+			_ = json.NewDecoder(request.Body).Decode(&params)
+			mu.Lock()
+			unregistered = append(unregistered, params.Methods...)
+			mu.Unlock()
+		}
+		_, _ = writer.Write([]byte(utils.ToJsonString(webresult.SUCCESS)))
+	}))
+	defer server.Close()
+	httpclients.Init(httpclients.Options{Timeout: time.Second * 5})
+	services.GetOpenApiService().SetHost(server.URL)
+
+	client := newExecutorClient(bean.ExecutorOptions{Tenant: "horace", AppName: "push", Tag: "common"})
+	client.started.Store(true)
+	if err := client.TryAddTask(DemoTask{}, bean.TaskOptions{Name: "a", Cron: "0 0 * * * ?"}); err != nil {
+		t.Fatal(err)
+	}
+	<-blocked
+
+	// 第一次推送还没有完成时多次修改任务
+	if err := client.RemoveTask("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.TryAddTask(DemoTask1{}, bean.TaskOptions{Name: "b", Cron: "0 0 * * * ?"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.TryAddTask(task.HandleError(DemoErrorTask{}), bean.TaskOptions{Name: "c", Cron: "0 0 * * * ?"}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+
+	deadline := time.Now().Add(time.Second * 3)
+	for {
+		mu.Lock()
+		count := len(registered)
+		mu.Unlock()
+		if count >= 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+	// 等待最新的推送完成，被合并的推送不会发送请求
+	client.registerMu.Lock()
+	client.registerMu.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(registered) != 2 || len(registered[1]) != 2 {
+		t.Errorf("pushes during a blocked push should be merged into one register of the latest tasks, registered: %v", registered)
+	}
+	if !reflect.DeepEqual(unregistered, []string{"push/cronjob.DemoTask.Handle"}) {
+		t.Errorf("removed task should be unregistered by the latest push, unregistered: %v", unregistered)
+	}
+}
@@ -0,0 +1,106 @@
+// Package handlers 内置的任务处理器，与自定义的处理器一样通过AddTask添加，参数从TaskParams.Params中读取JSON
+package handlers
+
+// Created in 2026-10-19 00:10.
+// @author Horace
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/horacedh/cronjob-executor/task"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaxOutputSize 默认每个输出最多保留的字节数
+const defaultMaxOutputSize = 64 * 1024
+
+// taskMethod 生成内置处理器的任务方法，同一类型的处理器按照名称区分，名称为空时使用类型名称
+func taskMethod(kind, name string) string {
+	if name == "" {
+		return "handlers." + kind + ".Handle"
+	}
+	return "handlers." + kind + "." + name + ".Handle"
+}
+
+// parseParams 解析JSON格式的任务参数，参数为空时不解析，解析失败时返回不需要重试的失败结果
+func parseParams(params *task.TaskParams, target any) *task.HandlerResult {
+	if strings.TrimSpace(params.Params) == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(params.Params), target); err != nil {
+		return task.FailedWithCode(1, fmt.Sprintf("invalid params: %v", err), task.CategoryPermanent)
+	}
+	return nil
+}
+
+// executionContext 处理器的执行上下文。timeout小于等于0时使用任务上下文，受任务超时时间（最大10秒）限制；
+// 大于0时使用timeout作为执行超时时间，忽略任务超时时间，但是任务被移除或者停机超时时仍然取消
+func executionContext(params *task.TaskParams, timeout time.Duration) (stdContext.Context, stdContext.CancelFunc) {
+	parent := params.Context()
+	if timeout <= 0 {
+		return parent, func() {}
+	}
+	ctx, cancel := stdContext.WithCancelCause(stdContext.WithoutCancel(parent))
+	ctx, cancelTimeout := stdContext.WithTimeout(ctx, timeout)
+	stop := stdContext.AfterFunc(parent, func() {
+		if cause := stdContext.Cause(parent); !errors.Is(cause, stdContext.DeadlineExceeded) {
+			cancel(cause)
+		}
+	})
+	return ctx, func() {
+		stop()
+		cancelTimeout()
+		cancel(nil)
+	}
+}
+
+// limitedBuffer 最多保留limit个字节的缓冲区，超出的部分丢弃并标记为截断，写入不会失败，可以被多个goroutine同时写入
+type limitedBuffer struct {
+	mu sync.Mutex
+	// limit 最多保留的字节数
+	limit int
+	// data 保留的数据
+	data []byte
+	// truncated 是否有数据被丢弃
+	truncated bool
+}
+
+// Write 写入数据，超出限制的部分丢弃
+func (buffer *limitedBuffer) Write(p []byte) (int, error) {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	remaining := buffer.limit - len(buffer.data)
+	if remaining < len(p) {
+		buffer.truncated = true
+		buffer.data = append(buffer.data, p[:max(remaining, 0)]...)
+	} else {
+		buffer.data = append(buffer.data, p...)
+	}
+	return len(p), nil
+}
+
+// String 保留的数据
+func (buffer *limitedBuffer) String() string {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	return string(buffer.data)
+}
+
+// Truncated 是否有数据被丢弃
+func (buffer *limitedBuffer) Truncated() bool {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	return buffer.truncated
+}
+
+// newLimitedBuffer 创建缓冲区，limit小于等于0时使用默认值
+func newLimitedBuffer(limit int) *limitedBuffer {
+	if limit <= 0 {
+		limit = defaultMaxOutputSize
+	}
+	return &limitedBuffer{limit: limit}
+}
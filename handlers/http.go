@@ -0,0 +1,274 @@
+package handlers
+
+// Created in 2026-10-19 00:45.
+// @author Horace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/horacedh/cronjob-executor/httpclients"
+	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/tracing"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultMaxBodySize 默认在结果中保留的响应体字节数
+const defaultMaxBodySize = 4 * 1024
+
+// maxSuccessBodySize 检查响应体中的成功字段时最多读取的字节数
+const maxSuccessBodySize = 1024 * 1024
+
+// HttpTask 调用HTTP接口的任务处理器，使用不携带执行器签名请求头的http.Client，超时时间取Client的超时时间和任务超时时间中较小的一个。
+// 处理器上的配置作为默认值，任务参数中的配置覆盖，处理器上配置了URL时任务参数不能修改URL，避免调度器调用任意地址
+type HttpTask struct {
+	// Name 名称，用于区分多个HTTP任务的任务方法
+	Name string
+	// URL 请求地址，为空时使用任务参数中的地址
+	URL string
+	// Method 请求方法，默认GET
+	Method string
+	// Headers 请求头，任务参数中的请求头追加或者覆盖
+	Headers map[string]string
+	// ExpectedStatuses 表示成功的状态码，默认200
+	ExpectedStatuses []int
+	// SuccessPath 响应体JSON中表示业务是否成功的字段路径，以.分隔，数组使用下标，例如data.items.0.ok，为空时不检查响应体
+	SuccessPath string
+	// SuccessValue 成功时字段的JSON值，例如0或者"OK"，为空时字段值为true表示成功
+	SuccessValue string
+	// MaxBodySize 结果中保留的响应体字节数，默认4KB
+	MaxBodySize int
+	// Client 发送请求的http.Client，为空时使用httpclients.NewPlainClient创建，超时时间与执行器的HttpClient一致
+	Client *http.Client
+}
+
+// HttpParams HTTP任务的参数，JSON格式放在TaskParams.Params中
+type HttpParams struct {
+	// Method 请求方法
+	Method string `json:"method,omitempty"`
+	// URL 请求地址，处理器上配置了URL时不能设置
+	URL string `json:"url,omitempty"`
+	// Headers 请求头
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body 请求体，JSON字符串按照原文发送，其他JSON值序列化后发送
+	Body json.RawMessage `json:"body,omitempty"`
+	// ExpectedStatuses 表示成功的状态码
+	ExpectedStatuses []int `json:"expectedStatuses,omitempty"`
+	// SuccessPath 响应体JSON中表示业务是否成功的字段路径
+	SuccessPath string `json:"successPath,omitempty"`
+	// SuccessValue 成功时字段的JSON值
+	SuccessValue json.RawMessage `json:"successValue,omitempty"`
+}
+
+// HttpOutput HTTP任务的输出数据
+type HttpOutput struct {
+	// Status 响应状态码，请求失败时为-1
+	Status int `json:"status"`
+	// Body 响应体，超过最大长度时截断
+	Body string `json:"body,omitempty"`
+	// BodyTruncated 响应体是否被截断
+	BodyTruncated bool `json:"bodyTruncated,omitempty"`
+	// Elapsed 请求耗时，毫秒
+	Elapsed int64 `json:"elapsed"`
+}
+
+// TaskMethod 任务方法
+func (httpTask *HttpTask) TaskMethod() string {
+	return taskMethod("HttpTask", httpTask.Name)
+}
+
+// Handle 发送请求，状态码不在成功状态码中或者响应体中的字段不是成功值时失败，4xx状态码（408和429除外）不需要重试
+func (httpTask *HttpTask) Handle(params *task.TaskParams) *task.HandlerResult {
+	httpParams := &HttpParams{}
+	if result := parseParams(params, httpParams); result != nil {
+		return result
+	}
+	request, err := httpTask.merge(httpParams)
+	if err != nil {
+		return task.FailedWithCode(1, err.Error(), task.CategoryPermanent)
+	}
+	client := httpTask.Client
+	if client == nil {
+		client = httpclients.NewPlainClient()
+	}
+
+	target := request.Method + " " + request.URL
+	var body io.Reader
+	if len(request.Body) > 0 {
+		body = bytes.NewReader(request.Body)
+	}
+	ctx := params.Context()
+	httpRequest, err := http.NewRequestWithContext(ctx, request.Method, request.URL, body)
+	if err != nil {
+		return task.FailedWithCode(1, fmt.Sprintf("invalid request %s: %v", target, err), task.CategoryPermanent)
+	}
+	for key, value := range request.Headers {
+		httpRequest.Header.Set(key, value)
+	}
+	if len(request.Body) > 0 && httpRequest.Header.Get("Content-Type") == "" {
+		httpRequest.Header.Set("Content-Type", "application/json")
+	}
+	tracing.Inject(ctx, httpRequest.Header)
+
+	maxBodySize := httpTask.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+	// 检查成功字段需要完整的响应体，最多读取maxSuccessBodySize个字节
+	readLimit := maxBodySize
+	if request.SuccessPath != "" {
+		readLimit = max(maxBodySize, maxSuccessBodySize)
+	}
+	startTime := time.Now()
+	response, err := client.Do(httpRequest)
+	output := &HttpOutput{Status: -1}
+	if err != nil {
+		output.Elapsed = time.Since(startTime).Milliseconds()
+		return task.Failed(fmt.Sprintf("request %s failed: %v", target, err)).WithOutput(output)
+	}
+	defer response.Body.Close()
+	responseBody, err := io.ReadAll(io.LimitReader(response.Body, int64(readLimit)+1))
+	output.Status, output.Elapsed = response.StatusCode, time.Since(startTime).Milliseconds()
+	output.Body, output.BodyTruncated = truncate(responseBody, maxBodySize)
+
+	switch {
+	case err != nil:
+		return task.Failed(fmt.Sprintf("request %s failed, read response body: %v", target, err)).WithOutput(output)
+	case !slices.Contains(request.ExpectedStatuses, output.Status):
+		category := task.ErrorCategory("")
+		if output.Status >= 400 && output.Status < 500 && output.Status != http.StatusRequestTimeout && output.Status != http.StatusTooManyRequests {
+			category = task.CategoryPermanent
+		}
+		return task.FailedWithCode(1, fmt.Sprintf("request %s returned unexpected status %d, expected: %v", target, output.Status, request.ExpectedStatuses), category).WithOutput(output)
+	}
+	if request.SuccessPath != "" {
+		if len(responseBody) > readLimit {
+			return task.Failed(fmt.Sprintf("request %s failed: response body exceeds %d bytes, can not check the success path", target, readLimit)).WithOutput(output)
+		}
+		if err = checkSuccessPath(responseBody, request.SuccessPath, request.SuccessValue); err != nil {
+			return task.Failed(fmt.Sprintf("request %s failed: %v", target, err)).WithOutput(output)
+		}
+	}
+	return task.Success().WithOutput(output)
+}
+
+// merge 合并处理器上的配置和任务参数，返回最终的请求配置
+func (httpTask *HttpTask) merge(httpParams *HttpParams) (*HttpParams, error) {
+	request := &HttpParams{
+		Method:           httpTask.Method,
+		URL:              httpTask.URL,
+		Headers:          make(map[string]string),
+		Body:             httpParams.Body,
+		ExpectedStatuses: httpTask.ExpectedStatuses,
+		SuccessPath:      httpTask.SuccessPath,
+		SuccessValue:     json.RawMessage(httpTask.SuccessValue),
+	}
+	if httpParams.URL != "" {
+		if request.URL != "" && httpParams.URL != request.URL {
+			return nil, fmt.Errorf("url is fixed by the handler and can not be changed by params")
+		}
+		request.URL = httpParams.URL
+	}
+	if request.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	if httpParams.Method != "" {
+		request.Method = httpParams.Method
+	}
+	request.Method = strings.ToUpper(request.Method)
+	if request.Method == "" {
+		request.Method = http.MethodGet
+	}
+	for key, value := range httpTask.Headers {
+		request.Headers[key] = value
+	}
+	for key, value := range httpParams.Headers {
+		request.Headers[key] = value
+	}
+	if len(httpParams.ExpectedStatuses) > 0 {
+		request.ExpectedStatuses = httpParams.ExpectedStatuses
+	}
+	if len(request.ExpectedStatuses) == 0 {
+		request.ExpectedStatuses = []int{http.StatusOK}
+	}
+	if httpParams.SuccessPath != "" {
+		request.SuccessPath = httpParams.SuccessPath
+	}
+	if len(httpParams.SuccessValue) > 0 {
+		request.SuccessValue = httpParams.SuccessValue
+	}
+
+	// JSON字符串类型的请求体按照原文发送，例如表单或者纯文本
+	var text string
+	if len(request.Body) > 0 && json.Unmarshal(request.Body, &text) == nil {
+		request.Body = []byte(text)
+	}
+	return request, nil
+}
+
+// checkSuccessPath 检查响应体JSON中指定路径的字段值是否为成功值，成功值为空时字段值需要为true
+func checkSuccessPath(body []byte, path string, successValue json.RawMessage) error {
+	var document any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return fmt.Errorf("response body is not json: %v", err)
+	}
+	value, ok := lookupPath(document, path)
+	if !ok {
+		return fmt.Errorf("success path %s not found in response body", path)
+	}
+	var expected any = true
+	if len(successValue) > 0 {
+		if err := json.Unmarshal(successValue, &expected); err != nil {
+			return fmt.Errorf("invalid success value %s: %v", successValue, err)
+		}
+	}
+	if !reflect.DeepEqual(value, expected) {
+		actual, _ := json.Marshal(value)
+		expectedJson, _ := json.Marshal(expected)
+		return fmt.Errorf("success path %s is %s, expected: %s", path, actual, expectedJson)
+	}
+	return nil
+}
+
+// lookupPath 按照.分隔的路径查找JSON字段，数组使用下标
+func lookupPath(document any, path string) (any, bool) {
+	current := document
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// truncate 截断数据，截断位置不会拆开多字节的UTF-8字符
+func truncate(data []byte, limit int) (string, bool) {
+	if len(data) <= limit {
+		return string(data), false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	return string(data[:cut]), true
+}
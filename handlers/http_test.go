@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/horacedh/cronjob-executor/httpclients"
+	"github.com/horacedh/cronjob-executor/task"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-19 00:55.
+// @author Horace
+
+// TestHttpTask 测试调用HTTP接口，检查状态码和响应体中的成功字段，结果中记录状态码和截断的响应体
+func TestHttpTask(t *testing.T) {
+	httpclients.Init(httpclients.Options{Timeout: time.Second})
+	var received *http.Request
+	var receivedBody string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received = request
+		body, _ := io.ReadAll(request.Body)
+		receivedBody = string(body)
+		switch request.URL.Path {
+		case "/ok":
+			_, _ = writer.Write([]byte(`{"code":0,"data":{"items":[{"ok":true}]},"msg":"处理完成，共处理100条数据"}`))
+		case "/failed":
+			_, _ = writer.Write([]byte(`{"code":500,"msg":"failed"}`))
+		case "/missing":
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = writer.Write([]byte("page not found"))
+		case "/large":
+			_, _ = writer.Write([]byte(`{"code":0,"data":"` + strings.Repeat("a", maxSuccessBodySize) + `"}`))
+		default:
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	httpTask := &HttpTask{Name: "callout", Method: "post", Headers: map[string]string{"X-App": "cronjob"}, SuccessPath: "code", SuccessValue: "0", MaxBodySize: 40}
+	if method := httpTask.TaskMethod(); method != "handlers.HttpTask.callout.Handle" {
+		t.Errorf("unexpected task method: %s", method)
+	}
+	result := httpTask.Handle(&task.TaskParams{Params: `{"url":"` + server.URL + `/ok","headers":{"x-request":"1"},"body":{"date":"2026-10-18"}}`})
+	output := result.Output.(*HttpOutput)
+	if !result.IsSuccess() || output.Status != 200 || !output.BodyTruncated || len(output.Body) > 40 || !strings.HasPrefix(output.Body, `{"code":0`) {
+		t.Errorf("request should succeed with truncated body, result: %+v, output: %+v", result, output)
+	}
+	if received.Method != "POST" || received.Header.Get("X-App") != "cronjob" || received.Header.Get("X-Request") != "1" ||
+		received.Header.Get("Content-Type") != "application/json" || receivedBody != `{"date":"2026-10-18"}` {
+		t.Errorf("unexpected request: %s %v, body: %s", received.Method, received.Header, receivedBody)
+	}
+	for _, header := range []string{"Sign", "Times", "Token"} {
+		if received.Header.Get(header) != "" {
+			t.Errorf("request to third-party url should not carry the executor header %s", header)
+		}
+	}
+	if _, err := json.Marshal(result.Output); err != nil {
+		t.Errorf("truncated body should be valid utf-8, err: %v", err)
+	}
+
+	result = httpTask.Handle(&task.TaskParams{Params: `{"url":"` + server.URL + `/ok","successPath":"data.items.0.ok","successValue":true,"body":"a=1"}`})
+	if !result.IsSuccess() || receivedBody != "a=1" {
+		t.Errorf("boolean success path should succeed and string body sent as is, result: %+v, body: %s", result, receivedBody)
+	}
+	result = httpTask.Handle(&task.TaskParams{Params: `{"url":"` + server.URL + `/failed"}`})
+	if result.IsSuccess() || !strings.Contains(result.Msg, "success path code is 500, expected: 0") {
+		t.Errorf("business failure should fail, result: %+v", result)
+	}
+	result = httpTask.Handle(&task.TaskParams{Params: `{"url":"` + server.URL + `/missing","method":"get"}`})
+	if output = result.Output.(*HttpOutput); result.IsSuccess() || result.Category != task.CategoryPermanent || output.Status != 404 || output.Body != "page not found" || received.Method != "GET" {
+		t.Errorf("4xx should fail permanently with the response body, result: %+v, output: %+v", result, output)
+	}
+	result = httpTask.Handle(&task.TaskParams{Params: `{"url":"` + server.URL + `/large"}`})
+	if output = result.Output.(*HttpOutput); result.IsSuccess() || !strings.Contains(result.Msg, "response body exceeds") || !output.BodyTruncated || len(output.Body) > 40 {
+		t.Errorf("body larger than the read limit should fail the success path check, result: %+v, output: %+v", result, output)
+	}
+	result = httpTask.Handle(&task.TaskParams{Params: `{"url":"` + server.URL + `/busy"}`})
+	if result.IsSuccess() || result.Category != "" || !strings.Contains(result.Msg, "unexpected status 503") {
+		t.Errorf("5xx should fail and be retryable, result: %+v", result)
+	}
+	if result = (&HttpTask{SuccessPath: ""}).Handle(&task.TaskParams{Params: `{"url":"` + server.URL + `/busy","expectedStatuses":[503]}`}); !result.IsSuccess() {
+		t.Errorf("expected status should succeed, result: %+v", result)
+	}
+
+	fixed := &HttpTask{URL: server.URL + "/ok"}
+	for params, problem := range map[string]string{`{"url":"http://other"}`: "url is fixed", `{"url":`: "invalid params"} {
+		if result = fixed.Handle(&task.TaskParams{Params: params}); result.Category != task.CategoryPermanent || !strings.Contains(result.Msg, problem) {
+			t.Errorf("params %s should fail permanently with %q, result: %+v", params, problem, result)
+		}
+	}
+	if result = (&HttpTask{}).Handle(&task.TaskParams{Params: `{"url":"` + server.URL + `/ok?date=2026-10-18"}`}); !result.IsSuccess() || received.URL.RawQuery != "date=2026-10-18" {
+		t.Errorf("query string of the url should be kept, result: %+v, query: %s", result, received.URL.RawQuery)
+	}
+	if result = (&HttpTask{}).Handle(&task.TaskParams{Params: `{"url":"http://[::1"}`}); result.Category != task.CategoryPermanent || !strings.Contains(result.Msg, "invalid request") {
+		t.Errorf("invalid url should fail permanently, result: %+v", result)
+	}
+	if result = (&HttpTask{}).Handle(&task.TaskParams{}); !strings.Contains(result.Msg, "url is required") {
+		t.Errorf("url should be required, result: %+v", result)
+	}
+}
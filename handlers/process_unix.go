@@ -0,0 +1,19 @@
+//go:build !windows
+
+package handlers
+
+// Created in 2026-10-19 00:20.
+// @author Horace
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup 命令在新的进程组中执行，取消时杀死整个进程组，包括命令启动的子进程
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package handlers
+
+// Created in 2026-10-19 00:20.
+// @author Horace
+
+import (
+	"os/exec"
+)
+
+// killProcessGroup Windows没有进程组信号，取消时只杀死命令进程
+func killProcessGroup(cmd *exec.Cmd) {
+}
@@ -0,0 +1,160 @@
+package handlers
+
+// Created in 2026-10-19 00:15.
+// @author Horace
+
+import (
+	stdContext "context"
+	"errors"
+	"fmt"
+	"github.com/horacedh/cronjob-executor/task"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// shellWaitDelay 进程被杀死或者退出后，等待子进程关闭输出管道的最长时间
+const shellWaitDelay = time.Second
+
+// ShellTask 执行命令的任务处理器，任务超时或者执行器停机时杀死整个进程组。
+// 处理器上配置的命令、参数、环境变量和工作目录作为默认值，任务参数中的配置覆盖或者追加。
+// 处理器上配置了命令时，任务参数不能修改命令和工作目录，只能追加AllowArgs允许的命令参数和AllowedEnv中的环境变量，
+// 避免调度器通过PATH、LD_PRELOAD或者工作目录执行其他程序。命令是sh -c之类的解释器时，允许追加参数等于允许执行任意命令
+type ShellTask struct {
+	// Name 名称，用于区分多个Shell任务的任务方法
+	Name string
+	// Command 命令，为空时使用任务参数中的命令，此时任务参数可以设置全部配置
+	Command string
+	// Args 命令参数，任务参数中的命令参数追加在后面
+	Args []string
+	// AllowArgs 配置了命令时，是否允许任务参数追加命令参数
+	AllowArgs bool
+	// AllowedEnv 配置了命令时，任务参数可以设置的环境变量名称
+	AllowedEnv []string
+	// Env 环境变量，格式为KEY=VALUE，在当前进程的环境变量基础上追加
+	Env []string
+	// Dir 工作目录，为空时使用当前进程的工作目录
+	Dir string
+	// MaxOutputSize 标准输出和标准错误各自最多保留的字节数，默认64KB
+	MaxOutputSize int
+	// ExecutionTimeout 执行超时时间，默认0使用任务超时时间，最大10秒。
+	// 大于0时命令最多执行此时间，超过任务超时时间后调度器可能认为执行失败并按照失败策略重试，需要同时调整调度器上的任务配置
+	ExecutionTimeout time.Duration
+}
+
+// ShellParams Shell任务的参数，JSON格式放在TaskParams.Params中
+type ShellParams struct {
+	// Command 命令，处理器上配置了命令时不能设置
+	Command string `json:"command,omitempty"`
+	// Args 命令参数
+	Args []string `json:"args,omitempty"`
+	// Env 环境变量
+	Env map[string]string `json:"env,omitempty"`
+	// Dir 工作目录
+	Dir string `json:"dir,omitempty"`
+}
+
+// ShellOutput Shell任务的输出数据
+type ShellOutput struct {
+	// ExitCode 退出码，进程没有启动或者被信号杀死时为-1
+	ExitCode int `json:"exitCode"`
+	// Stdout 标准输出
+	Stdout string `json:"stdout,omitempty"`
+	// StdoutTruncated 标准输出是否超过最大长度被截断
+	StdoutTruncated bool `json:"stdoutTruncated,omitempty"`
+	// Stderr 标准错误
+	Stderr string `json:"stderr,omitempty"`
+	// StderrTruncated 标准错误是否超过最大长度被截断
+	StderrTruncated bool `json:"stderrTruncated,omitempty"`
+}
+
+// TaskMethod 任务方法
+func (shellTask *ShellTask) TaskMethod() string {
+	return taskMethod("ShellTask", shellTask.Name)
+}
+
+// Handle 执行命令，退出码为0时成功，非0时失败并使用退出码作为结果编码，命令不存在或者不能执行时不需要重试
+func (shellTask *ShellTask) Handle(params *task.TaskParams) *task.HandlerResult {
+	shellParams := &ShellParams{}
+	if result := parseParams(params, shellParams); result != nil {
+		return result
+	}
+	command := shellTask.Command
+	if command != "" {
+		if err := shellTask.checkFixed(shellParams); err != nil {
+			return task.FailedWithCode(1, err.Error(), task.CategoryPermanent)
+		}
+	} else {
+		command = shellParams.Command
+	}
+	if command == "" {
+		return task.FailedWithCode(1, "command is required", task.CategoryPermanent)
+	}
+
+	ctx, cancel := executionContext(params, shellTask.ExecutionTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command, append(append([]string{}, shellTask.Args...), shellParams.Args...)...)
+	cmd.Dir = shellTask.Dir
+	if shellParams.Dir != "" {
+		cmd.Dir = shellParams.Dir
+	}
+	cmd.Env = append(os.Environ(), shellTask.Env...)
+	for key, value := range shellParams.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	stdout, stderr := newLimitedBuffer(shellTask.MaxOutputSize), newLimitedBuffer(shellTask.MaxOutputSize)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.WaitDelay = shellWaitDelay
+	killProcessGroup(cmd)
+
+	err := cmd.Run()
+	output := &ShellOutput{
+		ExitCode:        cmd.ProcessState.ExitCode(),
+		Stdout:          stdout.String(),
+		StdoutTruncated: stdout.Truncated(),
+		Stderr:          stderr.String(),
+		StderrTruncated: stderr.Truncated(),
+	}
+
+	var exitError *exec.ExitError
+	switch {
+	case err == nil:
+		return task.Success().WithOutput(output)
+	case ctx.Err() != nil:
+		return task.Failed(fmt.Sprintf("command %s killed: %v", command, stdContext.Cause(ctx))).WithOutput(output)
+	case errors.As(err, &exitError) && output.ExitCode > 0:
+		return task.FailedWithCode(int32(output.ExitCode), fmt.Sprintf("command %s exited with code %d: %s", command, output.ExitCode, lastLine(output.Stderr)), "").WithOutput(output)
+	case errors.As(err, &exitError):
+		return task.Failed(fmt.Sprintf("command %s terminated: %v", command, err)).WithOutput(output)
+	default:
+		// 命令不存在、没有执行权限或者工作目录不存在，重试也不会成功
+		return task.FailedWithCode(1, fmt.Sprintf("start command %s failed: %v", command, err), task.CategoryPermanent).WithOutput(output)
+	}
+}
+
+// checkFixed 处理器上配置了命令时，检查任务参数只修改了允许的配置
+func (shellTask *ShellTask) checkFixed(shellParams *ShellParams) error {
+	if shellParams.Command != "" && shellParams.Command != shellTask.Command {
+		return errors.New("command is fixed by the handler and can not be changed by params")
+	}
+	if shellParams.Dir != "" {
+		return errors.New("dir can not be changed by params when the command is fixed")
+	}
+	if len(shellParams.Args) > 0 && !shellTask.AllowArgs {
+		return errors.New("args can not be appended by params when the command is fixed")
+	}
+	for key := range shellParams.Env {
+		if !slices.Contains(shellTask.AllowedEnv, key) {
+			return fmt.Errorf("env %s can not be set by params when the command is fixed", key)
+		}
+	}
+	return nil
+}
+
+// lastLine 最后一行非空的输出，作为失败原因
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,108 @@
+//go:build !windows
+
+package handlers
+
+import (
+	stdContext "context"
+	"errors"
+	"github.com/horacedh/cronjob-executor/task"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-19 00:30.
+// @author Horace
+
+// TestShellTask 测试执行命令，参数、环境变量和工作目录生效，退出码映射为结果编码，输出超过最大长度时截断
+func TestShellTask(t *testing.T) {
+	shellTask := &ShellTask{Name: "script", Command: "sh", Args: []string{"-c"}, AllowArgs: true, AllowedEnv: []string{"NAME"}, Env: []string{"GREETING=hello"}, MaxOutputSize: 16}
+	if method := shellTask.TaskMethod(); method != "handlers.ShellTask.script.Handle" {
+		t.Errorf("unexpected task method: %s", method)
+	}
+
+	result := shellTask.Handle(&task.TaskParams{Params: `{"args":["echo $GREETING $NAME"],"env":{"NAME":"cron"}}`})
+	if output := result.Output.(*ShellOutput); !result.IsSuccess() || output.Stdout != "hello cron\n" {
+		t.Errorf("allowed args and env should be applied, result: %+v, output: %+v", result, output)
+	}
+
+	dir := t.TempDir()
+	result = (&ShellTask{MaxOutputSize: 16}).Handle(&task.TaskParams{Params: `{"command":"sh","args":["-c","echo $GREETING $NAME; pwd >&2"],"env":{"GREETING":"hello","NAME":"cron"},"dir":"` + dir + `"}`})
+	output := result.Output.(*ShellOutput)
+	if !result.IsSuccess() || output.ExitCode != 0 || output.Stdout != "hello cron\n" || output.StdoutTruncated {
+		t.Errorf("command should succeed, result: %+v, output: %+v", result, output)
+	}
+	if !output.StderrTruncated || !strings.HasPrefix(dir, output.Stderr) || len(output.Stderr) != 16 {
+		t.Errorf("stderr should be truncated to 16 bytes, output: %+v", output)
+	}
+
+	result = shellTask.Handle(&task.TaskParams{Params: `{"args":["echo first >&2; echo reason >&2; exit 3"]}`})
+	if result.Code != 3 || result.Category != "" || !strings.HasSuffix(result.Msg, "exited with code 3: reason") {
+		t.Errorf("exit code should be mapped to result code, result: %+v", result)
+	}
+
+	fixedTask := &ShellTask{Command: "echo", AllowedEnv: []string{"NAME"}}
+	for params, problem := range map[string]string{
+		`{"command":"rm"}`:                 "command is fixed",
+		`{"args":`:                         "invalid params",
+		`{"dir":"/tmp"}`:                   "dir can not be changed",
+		`{"args":["-n"]}`:                  "args can not be appended",
+		`{"env":{"PATH":"/tmp"}}`:          "env PATH can not be set",
+		`{"env":{"LD_PRELOAD":"evil.so"}}`: "env LD_PRELOAD can not be set",
+	} {
+		result = fixedTask.Handle(&task.TaskParams{Params: params})
+		if result.IsSuccess() || result.Category != task.CategoryPermanent || !strings.Contains(result.Msg, problem) {
+			t.Errorf("params %s should fail permanently with %q, result: %+v", params, problem, result)
+		}
+	}
+	if result = (&ShellTask{Command: "sh", Dir: "/missing"}).Handle(&task.TaskParams{}); result.Category != task.CategoryPermanent || !strings.Contains(result.Msg, "start command sh failed") {
+		t.Errorf("missing dir should fail permanently, result: %+v", result)
+	}
+	if result = (&ShellTask{}).Handle(&task.TaskParams{Params: `{"command":"cronjob-missing-command"}`}); result.Category != task.CategoryPermanent {
+		t.Errorf("missing command should fail permanently, result: %+v", result)
+	}
+}
+
+// TestShellTaskTimeout 测试任务超时后杀死整个进程组，后台运行的子进程持有输出管道也不会阻塞
+func TestShellTaskTimeout(t *testing.T) {
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Millisecond*200)
+	defer cancel()
+	params := &task.TaskParams{Params: `{"command":"sh","args":["-c","echo started; sleep 30 & sleep 30"]}`}
+	params.SetContext(ctx)
+
+	startTime := time.Now()
+	result := (&ShellTask{}).Handle(params)
+	if elapsed := time.Since(startTime); elapsed > shellWaitDelay {
+		t.Errorf("process group should be killed on timeout, elapsed: %v", elapsed)
+	}
+	output := result.Output.(*ShellOutput)
+	if result.IsSuccess() || !strings.Contains(result.Msg, "deadline exceeded") || output.ExitCode != -1 || output.Stdout != "started\n" {
+		t.Errorf("command should be killed, result: %+v, output: %+v", result, output)
+	}
+}
+
+// TestShellTaskExecutionTimeout 测试配置执行超时时间后不受任务超时时间限制，任务被取消时仍然杀死进程
+func TestShellTaskExecutionTimeout(t *testing.T) {
+	shellTask := &ShellTask{Command: "sh", Args: []string{"-c", "sleep 0.3; echo done"}, ExecutionTimeout: time.Second * 2}
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Millisecond*100)
+	defer cancel()
+	params := &task.TaskParams{}
+	params.SetContext(ctx)
+	if result := shellTask.Handle(params); !result.IsSuccess() || result.Output.(*ShellOutput).Stdout != "done\n" {
+		t.Errorf("command should run beyond the task timeout, result: %+v", result)
+	}
+
+	shellTask.ExecutionTimeout = time.Millisecond * 100
+	if result := shellTask.Handle(&task.TaskParams{}); result.IsSuccess() || !strings.Contains(result.Msg, "deadline exceeded") {
+		t.Errorf("command should be killed after the execution timeout, result: %+v", result)
+	}
+
+	shellTask.ExecutionTimeout = time.Second * 2
+	canceled, cancelCause := stdContext.WithCancelCause(stdContext.Background())
+	time.AfterFunc(time.Millisecond*100, func() { cancelCause(errors.New("task removed")) })
+	params = &task.TaskParams{}
+	params.SetContext(canceled)
+	if result := shellTask.Handle(params); result.IsSuccess() || !strings.Contains(result.Msg, "task removed") {
+		t.Errorf("command should be killed when the task is canceled, result: %+v", result)
+	}
+}
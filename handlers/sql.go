@@ -0,0 +1,137 @@
+package handlers
+
+// Created in 2026-10-19 01:10.
+// @author Horace
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/horacedh/cronjob-executor/task"
+	"strings"
+	"sync"
+	"time"
+)
+
+// databases 已注册的数据库，key为名称
+var databases sync.Map
+
+// RegisterDB 注册数据库，SQL任务按照名称使用，重复注册时替换，db为nil时移除
+func RegisterDB(name string, db *sql.DB) {
+	if db == nil {
+		databases.Delete(name)
+		return
+	}
+	databases.Store(name, db)
+}
+
+// lookupDB 根据名称查找已注册的数据库
+func lookupDB(name string) (*sql.DB, bool) {
+	db, ok := databases.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return db.(*sql.DB), true
+}
+
+// SqlTask 在事务中执行SQL或者存储过程的任务处理器，执行失败时回滚。
+// 语句的超时时间默认与任务的超时时间一致（最大10秒），可以通过ExecutionTimeout单独设置，超时后取消语句并回滚事务。
+// 处理器上配置了SQL或者存储过程时任务参数只能传递参数值，避免调度器执行任意SQL
+type SqlTask struct {
+	// Name 名称，用于区分多个SQL任务的任务方法
+	Name string
+	// DB 已注册的数据库名称
+	DB string
+	// SQL 执行的SQL，与Procedure二选一，为空时使用任务参数中的SQL
+	SQL string
+	// Procedure 执行的存储过程名称，使用CALL procedure(?, ...)调用
+	Procedure string
+	// ExecutionTimeout 执行超时时间，默认0使用任务超时时间。
+	// 大于0时语句最多执行此时间，超过任务超时时间后调度器可能认为执行失败并按照失败策略重试，需要同时调整调度器上的任务配置
+	ExecutionTimeout time.Duration
+}
+
+// SqlParams SQL任务的参数，JSON格式放在TaskParams.Params中
+type SqlParams struct {
+	// SQL 执行的SQL，处理器上配置了SQL或者存储过程时不能设置
+	SQL string `json:"sql,omitempty"`
+	// Procedure 执行的存储过程名称，处理器上配置了SQL或者存储过程时不能设置
+	Procedure string `json:"procedure,omitempty"`
+	// Args SQL或者存储过程的参数值
+	Args []any `json:"args,omitempty"`
+}
+
+// SqlOutput SQL任务的输出数据
+type SqlOutput struct {
+	// RowsAffected 影响的行数，数据库不支持时为-1
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+// TaskMethod 任务方法
+func (sqlTask *SqlTask) TaskMethod() string {
+	return taskMethod("SqlTask", sqlTask.Name)
+}
+
+// Handle 在事务中执行SQL或者存储过程，成功时提交事务，影响的行数记录在输出数据和rowsAffected计数中
+func (sqlTask *SqlTask) Handle(params *task.TaskParams) *task.HandlerResult {
+	sqlParams := &SqlParams{}
+	if result := parseParams(params, sqlParams); result != nil {
+		return result
+	}
+	statement, err := sqlTask.statement(sqlParams)
+	if err != nil {
+		return task.FailedWithCode(1, err.Error(), task.CategoryPermanent)
+	}
+	db, ok := lookupDB(sqlTask.DB)
+	if !ok {
+		return task.FailedWithCode(1, fmt.Sprintf("db %s is not registered", sqlTask.DB), task.CategoryPermanent)
+	}
+
+	ctx, cancel := executionContext(params, sqlTask.ExecutionTimeout)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return task.Failed(fmt.Sprintf("begin transaction on db %s failed: %v", sqlTask.DB, err))
+	}
+	result, err := tx.ExecContext(ctx, statement, sqlParams.Args...)
+	if err != nil {
+		_ = tx.Rollback()
+		return task.Failed(fmt.Sprintf("execute sql on db %s failed: %v", sqlTask.DB, err))
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		rowsAffected = -1
+	}
+	if err = tx.Commit(); err != nil {
+		return task.Failed(fmt.Sprintf("commit transaction on db %s failed: %v", sqlTask.DB, err))
+	}
+	handlerResult := task.Success().WithOutput(&SqlOutput{RowsAffected: rowsAffected})
+	if rowsAffected >= 0 {
+		handlerResult.WithCounter("rowsAffected", rowsAffected)
+	}
+	return handlerResult
+}
+
+// statement 合并处理器上的配置和任务参数，返回执行的语句
+func (sqlTask *SqlTask) statement(sqlParams *SqlParams) (string, error) {
+	if sqlTask.DB == "" {
+		return "", fmt.Errorf("db is required")
+	}
+	statement, procedure := sqlTask.SQL, sqlTask.Procedure
+	if sqlParams.SQL != "" || sqlParams.Procedure != "" {
+		if statement != "" || procedure != "" {
+			return "", fmt.Errorf("sql is fixed by the handler and can not be changed by params")
+		}
+		statement, procedure = sqlParams.SQL, sqlParams.Procedure
+	}
+	switch {
+	case statement != "" && procedure != "":
+		return "", fmt.Errorf("only one of sql and procedure can be set")
+	case statement != "":
+		return statement, nil
+	case procedure != "":
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(sqlParams.Args)), ", ")
+		return "CALL " + procedure + "(" + placeholders + ")", nil
+	default:
+		return "", fmt.Errorf("sql or procedure is required")
+	}
+}
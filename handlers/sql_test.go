@@ -0,0 +1,117 @@
+//go:build cgo
+
+package handlers
+
+import (
+	stdContext "context"
+	"database/sql"
+	"github.com/horacedh/cronjob-executor/task"
+	_ "github.com/mattn/go-sqlite3"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-19 01:20.
+// @author Horace
+
+// openTestDB 打开临时的sqlite数据库并注册
+func openTestDB(t *testing.T, name string) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), name+".db"))
+	if err != nil {
+		t.Fatalf("open db failed, err: %v", err)
+	}
+	t.Cleanup(func() {
+		RegisterDB(name, nil)
+		_ = db.Close()
+	})
+	if _, err = db.Exec("CREATE TABLE job_log (id INTEGER PRIMARY KEY, created INTEGER)"); err != nil {
+		t.Fatalf("create table failed, err: %v", err)
+	}
+	if _, err = db.Exec("INSERT INTO job_log (created) VALUES (1), (2), (3), (4)"); err != nil {
+		t.Fatalf("insert rows failed, err: %v", err)
+	}
+	RegisterDB(name, db)
+	return db
+}
+
+// count 表中的行数
+func count(t *testing.T, db *sql.DB) int {
+	var rows int
+	if err := db.QueryRow("SELECT count(*) FROM job_log").Scan(&rows); err != nil {
+		t.Fatalf("count rows failed, err: %v", err)
+	}
+	return rows
+}
+
+// TestSqlTask 测试在事务中执行SQL，记录影响的行数，失败时回滚
+func TestSqlTask(t *testing.T) {
+	db := openTestDB(t, "cleanup")
+	sqlTask := &SqlTask{Name: "cleanup", DB: "cleanup", SQL: "DELETE FROM job_log WHERE created < ?"}
+	if method := sqlTask.TaskMethod(); method != "handlers.SqlTask.cleanup.Handle" {
+		t.Errorf("unexpected task method: %s", method)
+	}
+
+	result := sqlTask.Handle(&task.TaskParams{Params: `{"args":[3]}`})
+	if !result.IsSuccess() || result.Output.(*SqlOutput).RowsAffected != 2 || result.Counters["rowsAffected"] != 2 || count(t, db) != 2 {
+		t.Errorf("sql should delete 2 rows, result: %+v", result)
+	}
+
+	// 违反唯一约束时回滚事务
+	result = (&SqlTask{DB: "cleanup"}).Handle(&task.TaskParams{Params: `{"sql":"INSERT INTO job_log (id, created) VALUES (100, 5), (3, 6)"}`})
+	if result.IsSuccess() || !strings.Contains(result.Msg, "UNIQUE constraint failed") || count(t, db) != 2 {
+		t.Errorf("failed sql should roll back, result: %+v, rows: %d", result, count(t, db))
+	}
+
+	for taskParams, problem := range map[string]string{
+		`{"sql":"DROP TABLE job_log"}`: "sql is fixed",
+		`{"procedure":"cleanup"}`:      "sql is fixed",
+		`{"args":`:                     "invalid params",
+	} {
+		if result = sqlTask.Handle(&task.TaskParams{Params: taskParams}); result.Category != task.CategoryPermanent || !strings.Contains(result.Msg, problem) {
+			t.Errorf("params %s should fail permanently with %q, result: %+v", taskParams, problem, result)
+		}
+	}
+	for _, invalid := range []*SqlTask{{DB: "missing", SQL: "SELECT 1"}, {SQL: "SELECT 1"}, {DB: "cleanup"}, {DB: "cleanup", SQL: "SELECT 1", Procedure: "cleanup"}} {
+		if result = invalid.Handle(&task.TaskParams{}); result.Category != task.CategoryPermanent {
+			t.Errorf("invalid sql task should fail permanently, task: %+v, result: %+v", invalid, result)
+		}
+	}
+	if statement, _ := (&SqlTask{DB: "cleanup", Procedure: "archive"}).statement(&SqlParams{Args: []any{1, "a"}}); statement != "CALL archive(?, ?)" {
+		t.Errorf("unexpected procedure statement: %s", statement)
+	}
+}
+
+// TestSqlTaskTimeout 测试语句超过任务超时时间后取消并回滚
+func TestSqlTaskTimeout(t *testing.T) {
+	db := openTestDB(t, "timeout")
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Millisecond*200)
+	defer cancel()
+	params := &task.TaskParams{Params: `{"sql":"WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 1000000000) INSERT INTO job_log (created) SELECT x FROM c"}`}
+	params.SetContext(ctx)
+
+	startTime := time.Now()
+	result := (&SqlTask{DB: "timeout"}).Handle(params)
+	if elapsed := time.Since(startTime); result.IsSuccess() || elapsed > time.Second*2 {
+		t.Fatalf("long running sql should be canceled, elapsed: %v, result: %+v", elapsed, result)
+	}
+	if rows := count(t, db); rows != 4 {
+		t.Errorf("canceled sql should roll back, rows: %d", rows)
+	}
+}
+
+// TestSqlTaskExecutionTimeout 测试配置执行超时时间后语句不受任务超时时间限制
+func TestSqlTaskExecutionTimeout(t *testing.T) {
+	db := openTestDB(t, "execution")
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Millisecond*50)
+	defer cancel()
+	params := &task.TaskParams{Params: `{"sql":"WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 300000) INSERT INTO job_log (created) SELECT x FROM c"}`}
+	params.SetContext(ctx)
+	time.Sleep(time.Millisecond * 60)
+
+	result := (&SqlTask{DB: "execution", ExecutionTimeout: time.Second * 10}).Handle(params)
+	if !result.IsSuccess() || count(t, db) != 300004 {
+		t.Errorf("sql should run beyond the task timeout, result: %+v", result)
+	}
+}
@@ -5,9 +5,11 @@ package httpclients
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	logger "github.com/cihub/seelog"
+	"github.com/horacedh/cronjob-executor/tracing"
 	"github.com/horacedh/cronjob-executor/utils"
 	"github.com/horacedh/cronjob-executor/webresult"
 	"io"
@@ -20,6 +22,9 @@ import (
 	"time"
 )
 
+// DefaultTimeout 执行器HttpClient的默认超时时间
+const DefaultTimeout = time.Second * 5
+
 // 单例模式
 var (
 	httpClient     HttpClient
@@ -134,6 +139,10 @@ type HttpClient interface {
 	GetRequest(url string, headers map[string]interface{}, params map[string]interface{}, body io.Reader) HttpResult
 	// PostRequest 发送Post请求，返回结果
 	PostRequest(url string, headers map[string]interface{}, params map[string]interface{}, body io.Reader) HttpResult
+	// RequestWithContext 携带上下文发送HTTP请求，上下文中的链路信息会以W3C traceparent请求头传递
+	RequestWithContext(ctx context.Context, url string, method string, headers map[string]interface{}, params map[string]interface{}, body io.Reader) HttpResult
+	// PostRequestWithContext 携带上下文发送Post请求，返回结果
+	PostRequestWithContext(ctx context.Context, url string, headers map[string]interface{}, params map[string]interface{}, body io.Reader) HttpResult
 }
 
 // HttpClientImpl 实现类
@@ -152,6 +161,11 @@ func (httpClient *httpClientImpl) PostRequest(url string, headers map[string]int
 	return httpClient.Request(url, "POST", headers, params, body)
 }
 
+// PostRequestWithContext 携带上下文发送Post请求，返回结果
+func (httpClient *httpClientImpl) PostRequestWithContext(ctx context.Context, url string, headers map[string]interface{}, params map[string]interface{}, body io.Reader) HttpResult {
+	return httpClient.RequestWithContext(ctx, url, "POST", headers, params, body)
+}
+
 // encodeParams 编码参数
 func encodeParams(params map[string]interface{}) string {
 	if params != nil {
@@ -172,6 +186,11 @@ func encodeParams(params map[string]interface{}) string {
 
 // Request 发送HTTP请求，返回字节数组
 func (httpClient *httpClientImpl) Request(url string, method string, headers map[string]interface{}, params map[string]interface{}, body io.Reader) HttpResult {
+	return httpClient.RequestWithContext(context.Background(), url, method, headers, params, body)
+}
+
+// RequestWithContext 携带上下文发送HTTP请求，返回字节数组
+func (httpClient *httpClientImpl) RequestWithContext(ctx context.Context, url string, method string, headers map[string]interface{}, params map[string]interface{}, body io.Reader) HttpResult {
 	now := time.Now()
 	// 设置参数
 	paramString := encodeParams(params)
@@ -185,11 +204,10 @@ func (httpClient *httpClientImpl) Request(url string, method string, headers map
 	var request *http.Request
 	var err error
 	if strings.EqualFold("POST", method) && body == nil {
-		request, err = http.NewRequest(method, url, strings.NewReader(paramString))
+		request, err = http.NewRequestWithContext(ctx, method, url, strings.NewReader(paramString))
 	} else {
-		request, err = http.NewRequest(method, url, body)
+		request, err = http.NewRequestWithContext(ctx, method, url, body)
 	}
-	request.URL.RawQuery = paramString
 	if err != nil {
 		logger.Errorf("create request error, method: %s, url: %s, headers: %v, params: %v", method, url, headers, params)
 		return HttpResult{
@@ -202,6 +220,13 @@ func (httpClient *httpClientImpl) Request(url string, method string, headers map
 			Elapsed:       time.Since(now).Milliseconds(),
 		}
 	}
+	// 保留url中原有的查询参数，params追加在后面
+	if paramString != "" {
+		if request.URL.RawQuery != "" {
+			paramString = request.URL.RawQuery + "&" + paramString
+		}
+		request.URL.RawQuery = paramString
+	}
 
 	if headers == nil {
 		headers = make(map[string]interface{})
@@ -233,6 +258,9 @@ func (httpClient *httpClientImpl) Request(url string, method string, headers map
 		}
 	}
 
+	// 传递链路追踪信息
+	tracing.Inject(ctx, request.Header)
+
 	// 发送HTTP请求
 	response, err := httpClient.client.Do(request)
 	code := -1
@@ -316,6 +344,21 @@ func Init(options Options) HttpClient {
 	return httpClient
 }
 
+// NewPlainClient 创建不携带签名请求头的http.Client，用于调用第三方接口，避免泄露执行器的签名。
+// 超时时间与执行器的HttpClient一致，没有初始化时使用DefaultTimeout，不自动跟随重定向
+func NewPlainClient() *http.Client {
+	timeout := DefaultTimeout
+	if impl, ok := httpClient.(*httpClientImpl); ok && impl.Options.Timeout > 0 {
+		timeout = impl.Options.Timeout
+	}
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
 // GetHttpClient 获取实例对象，需要先调用Init方法初始化
 func GetHttpClient() HttpClient {
 	return httpClient
@@ -0,0 +1,40 @@
+package httpclients
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-19 09:20.
+// @author Horace
+
+// TestRequestQuery 测试url中的查询参数会被保留，params追加在后面
+func TestRequestQuery(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		query = request.URL.RawQuery
+		_, _ = writer.Write([]byte(`{"code":0}`))
+	}))
+	defer server.Close()
+
+	client := &httpClientImpl{Options: &Options{Timeout: time.Second}, client: &http.Client{Timeout: time.Second}}
+	if result := client.GetRequest(server.URL+"/api?page=1", nil, nil, nil); result.Err != nil || query != "page=1" {
+		t.Errorf("query of the url should be kept, query: %s, err: %v", query, result.Err)
+	}
+	if result := client.GetRequest(server.URL+"/api?page=1", nil, map[string]interface{}{"size": 10}, nil); result.Err != nil || query != "page=1&size=10" {
+		t.Errorf("params should be appended to the query of the url, query: %s, err: %v", query, result.Err)
+	}
+	if result := client.GetRequest(server.URL+"/api", nil, map[string]interface{}{"size": 10}, nil); result.Err != nil || query != "size=10" {
+		t.Errorf("params should be set as the query, query: %s, err: %v", query, result.Err)
+	}
+}
+
+// TestRequestInvalidUrl 测试url不合法时返回错误而不是panic
+func TestRequestInvalidUrl(t *testing.T) {
+	client := &httpClientImpl{Options: &Options{Timeout: time.Second}, client: &http.Client{Timeout: time.Second}}
+	if result := client.GetRequest("http://[::1", nil, map[string]interface{}{"size": 10}, nil); result.Err == nil || result.Status != -1 {
+		t.Errorf("invalid url should return an error, result: %+v", result)
+	}
+}
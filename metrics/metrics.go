@@ -0,0 +1,127 @@
+package metrics
+
+// Created in 2026-10-18 10:12.
+// @author Horace
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+// namespace 指标名称前缀
+const namespace = "cronjob_executor"
+
+// 任务执行结果标签值
+const (
+	// ResultSuccess 执行成功
+	ResultSuccess = "success"
+	// ResultSkipped 执行成功，没有需要处理的数据
+	ResultSkipped = "skipped"
+	// ResultFailed 执行失败
+	ResultFailed = "failed"
+	// ResultPanic 执行发生异常
+	ResultPanic = "panic"
+	// ResultNotFound 未找到执行方法
+	ResultNotFound = "not_found"
+	// ResultFailure 请求失败，用于心跳和注册
+	ResultFailure = "failure"
+)
+
+// registry 执行器独立的指标注册中心，避免与业务方的默认注册中心冲突
+var registry = prometheus.NewRegistry()
+
+var (
+	// DispatcherQueueSize 调度队列中等待执行的任务数
+	DispatcherQueueSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dispatcher_queue_size",
+		Help:      "Number of tasks waiting in the dispatcher queue.",
+	})
+	// DispatchDelay 任务计划执行时间与实际开始执行时间之间的延迟
+	DispatchDelay = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "dispatch_delay_seconds",
+		Help:      "Delay between the planned execution time and the real start of a task.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	})
+	// HandlerDuration 任务处理方法耗时
+	HandlerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "handler_duration_seconds",
+		Help:      "Duration of task handler invocations.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"method"})
+	// TaskResults 任务执行结果计数，按执行结果区分
+	TaskResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_results_total",
+		Help:      "Number of task executions by method and result state.",
+	}, []string{"method", "state"})
+	// ResultQueueSize 等待发送给调度器的任务结果数
+	ResultQueueSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "result_queue_size",
+		Help:      "Number of task results waiting to be sent to the scheduler.",
+	})
+	// ResultSendFailures 发送任务结果失败次数
+	ResultSendFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "result_send_failures_total",
+		Help:      "Number of failed attempts to send a task result to the scheduler.",
+	})
+	// Heartbeats 心跳请求次数，按结果区分
+	Heartbeats = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "heartbeats_total",
+		Help:      "Number of heartbeat requests by result.",
+	}, []string{"result"})
+	// DuplicateDispatches 重复的调度请求次数，调度器超时重试时同一个任务日志ID只执行一次
+	DuplicateDispatches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "duplicate_dispatches_total",
+		Help:      "Number of dispatch requests ignored because the task log id was already queued, running or recently finished.",
+	})
+	// Registrations 注册请求次数，按类型（executor、task、task_unregister）和结果区分
+	Registrations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "Number of executor and task registration requests by type and result.",
+	}, []string{"type", "result"})
+)
+
+// init 注册所有指标
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		DispatcherQueueSize,
+		DispatchDelay,
+		HandlerDuration,
+		TaskResults,
+		ResultQueueSize,
+		ResultSendFailures,
+		Heartbeats,
+		Registrations,
+		DuplicateDispatches,
+	)
+}
+
+// Result 将布尔结果转换为标签值
+func Result(success bool) string {
+	if success {
+		return ResultSuccess
+	}
+	return ResultFailure
+}
+
+// Registry 获取执行器的指标注册中心，业务方可以向其中注册自定义指标
+func Registry() *prometheus.Registry {
+	return registry
+}
+
+// Handler 获取Prometheus文本格式的指标输出处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,211 @@
+package services
+
+// Created in 2026-10-18 21:55.
+// @author Horace
+
+import (
+	"errors"
+	"fmt"
+	logger "github.com/cihub/seelog"
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/task"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkpointRetention 本地文件检查点的保留时间，超过此时间没有更新的检查点会被清理
+const checkpointRetention = time.Hour * 24 * 7
+
+// checkpointSweepInterval 清理过期检查点的间隔时间，创建存储时清理一次，之后保存检查点时距离上一次清理超过此时间再次清理
+const checkpointSweepInterval = time.Hour
+
+// checkpointSuffix 本地文件检查点的文件后缀
+const checkpointSuffix = ".checkpoint"
+
+// fileCheckpointStore 本地文件检查点存储，每个检查点一个文件，先写临时文件再重命名，进程崩溃时不会留下写了一半的检查点
+type fileCheckpointStore struct {
+	mu sync.Mutex
+	// dir 检查点目录
+	dir string
+	// lastSweep 上一次清理过期检查点的时间
+	lastSweep time.Time
+}
+
+// NewFileCheckpointStore 创建本地文件检查点存储，目录不存在时自动创建，并清理过期的检查点，长时间运行时保存检查点会定期清理
+func NewFileCheckpointStore(dir string) (task.CheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create checkpoint dir failed: %w", err)
+	}
+	store := &fileCheckpointStore{dir: dir}
+	store.sweep(time.Now())
+	return store, nil
+}
+
+// lazyCheckpointStore 第一次加载、保存或者删除检查点时才创建的检查点存储，没有使用检查点的执行器不会创建目录
+type lazyCheckpointStore struct {
+	mu sync.Mutex
+	// create 创建实际的检查点存储
+	create func() (task.CheckpointStore, error)
+	// store 已经创建的检查点存储
+	store task.CheckpointStore
+}
+
+// NewLazyCheckpointStore 创建延迟创建的检查点存储，创建失败时本次调用返回错误，下一次调用重新创建
+func NewLazyCheckpointStore(create func() (task.CheckpointStore, error)) task.CheckpointStore {
+	return &lazyCheckpointStore{create: create}
+}
+
+// get 获取实际的检查点存储，还没有创建时创建
+func (lazy *lazyCheckpointStore) get() (task.CheckpointStore, error) {
+	lazy.mu.Lock()
+	defer lazy.mu.Unlock()
+	if lazy.store == nil {
+		store, err := lazy.create()
+		if err != nil {
+			return nil, err
+		}
+		lazy.store = store
+	}
+	return lazy.store, nil
+}
+
+// Load 加载检查点
+func (lazy *lazyCheckpointStore) Load(key task.CheckpointKey) ([]byte, error) {
+	store, err := lazy.get()
+	if err != nil {
+		return nil, err
+	}
+	return store.Load(key)
+}
+
+// Save 保存检查点
+func (lazy *lazyCheckpointStore) Save(key task.CheckpointKey, cursor []byte) error {
+	store, err := lazy.get()
+	if err != nil {
+		return err
+	}
+	return store.Save(key, cursor)
+}
+
+// Delete 删除检查点
+func (lazy *lazyCheckpointStore) Delete(key task.CheckpointKey) error {
+	store, err := lazy.get()
+	if err != nil {
+		return err
+	}
+	return store.Delete(key)
+}
+
+// Load 加载检查点
+func (store *fileCheckpointStore) Load(key task.CheckpointKey) ([]byte, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	cursor, err := os.ReadFile(store.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return cursor, err
+}
+
+// Save 保存检查点
+func (store *fileCheckpointStore) Save(key task.CheckpointKey, cursor []byte) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if now := time.Now(); now.Sub(store.lastSweep) > checkpointSweepInterval {
+		store.sweep(now)
+	}
+	path := store.path(key)
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, cursor, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// Delete 删除检查点
+func (store *fileCheckpointStore) Delete(key task.CheckpointKey) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if err := os.Remove(store.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path 检查点文件路径
+func (store *fileCheckpointStore) path(key task.CheckpointKey) string {
+	return filepath.Join(store.dir, key.String()+checkpointSuffix)
+}
+
+// sweep 清理超过保留时间没有更新的检查点，调用方需要持有锁或者还没有发布存储
+func (store *fileCheckpointStore) sweep(now time.Time) {
+	store.lastSweep = now
+	before := now.Add(-checkpointRetention)
+	entries, err := os.ReadDir(store.dir)
+	if err != nil {
+		logger.Warnf("read checkpoint dir failed, dir:%s, err:%v", store.dir, err)
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), checkpointSuffix) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.ModTime().Before(before) {
+			logger.Infof("remove expired checkpoint, file:%s", entry.Name())
+			_ = os.Remove(filepath.Join(store.dir, entry.Name()))
+		}
+	}
+}
+
+// openApiCheckpointStore 通过OpenApi保存在调度器中的检查点存储
+type openApiCheckpointStore struct {
+	// tenant 租户编码
+	tenant string
+	// appName 应用名
+	appName string
+}
+
+// NewOpenApiCheckpointStore 创建通过OpenApi保存在调度器中的检查点存储
+func NewOpenApiCheckpointStore(options bean.ExecutorOptions) task.CheckpointStore {
+	return &openApiCheckpointStore{tenant: options.Tenant, appName: options.AppName}
+}
+
+// Load 加载检查点
+func (store *openApiCheckpointStore) Load(key task.CheckpointKey) ([]byte, error) {
+	cursor, success := GetOpenApiService().GetCheckpoint(store.params(key, nil))
+	if !success {
+		return nil, fmt.Errorf("get checkpoint %s failed", key)
+	}
+	return cursor, nil
+}
+
+// Save 保存检查点
+func (store *openApiCheckpointStore) Save(key task.CheckpointKey, cursor []byte) error {
+	if !GetOpenApiService().SaveCheckpoint(store.params(key, cursor)) {
+		return fmt.Errorf("save checkpoint %s failed", key)
+	}
+	return nil
+}
+
+// Delete 删除检查点
+func (store *openApiCheckpointStore) Delete(key task.CheckpointKey) error {
+	if !GetOpenApiService().DeleteCheckpoint(store.params(key, nil)) {
+		return fmt.Errorf("delete checkpoint %s failed", key)
+	}
+	return nil
+}
+
+// params 构造检查点请求参数
+func (store *openApiCheckpointStore) params(key task.CheckpointKey, cursor []byte) bean.CheckpointParams {
+	return bean.CheckpointParams{
+		Tenant:       store.tenant,
+		AppName:      store.appName,
+		TaskId:       key.TaskId,
+		Page:         key.Page,
+		ScheduleTime: key.ScheduleTime,
+		Cursor:       cursor,
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"encoding/json"
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/httpclients"
+	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/utils"
+	"github.com/horacedh/cronjob-executor/webresult"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-18 22:10.
+// @author Horace
+
+// TestFileCheckpointStore 测试本地文件检查点的保存、覆盖、删除和定期的过期清理
+func TestFileCheckpointStore(t *testing.T) {
+	dir := t.TempDir()
+	expired := filepath.Join(dir, "1-1-1000"+checkpointSuffix)
+	if err := os.WriteFile(expired, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldTime := time.Now().Add(-checkpointRetention - time.Hour)
+	_ = os.Chtimes(expired, oldTime, oldTime)
+
+	store, err := NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Errorf("expired checkpoint should be removed")
+	}
+
+	key := task.CheckpointKey{TaskId: 2, Page: 3, ScheduleTime: 2000}
+	if cursor, err := store.Load(key); cursor != nil || err != nil {
+		t.Errorf("missing checkpoint should be nil, cursor: %v, err: %v", cursor, err)
+	}
+	_ = store.Save(key, []byte("a"))
+	_ = store.Save(key, []byte("b"))
+	if cursor, err := store.Load(key); string(cursor) != "b" || err != nil {
+		t.Errorf("checkpoint not overwritten, cursor: %s, err: %v", cursor, err)
+	}
+	if err := store.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(key); err != nil {
+		t.Errorf("delete missing checkpoint should not fail, err: %v", err)
+	}
+
+	// 长时间运行时保存检查点会再次清理过期的检查点
+	_ = os.WriteFile(expired, []byte("old"), 0644)
+	_ = os.Chtimes(expired, oldTime, oldTime)
+	_ = store.Save(key, []byte("c"))
+	if _, err := os.Stat(expired); err != nil {
+		t.Errorf("checkpoint should not be swept again within the sweep interval, err: %v", err)
+	}
+	store.(*fileCheckpointStore).lastSweep = time.Now().Add(-checkpointSweepInterval - time.Minute)
+	_ = store.Save(key, []byte("d"))
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Errorf("expired checkpoint should be removed by the periodic sweep")
+	}
+}
+
+// TestLazyCheckpointStore 测试第一次使用检查点时才创建目录，创建失败时返回错误并在下一次使用时重新创建
+func TestLazyCheckpointStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoints")
+	var creates int
+	store := NewLazyCheckpointStore(func() (task.CheckpointStore, error) {
+		creates++
+		if creates == 1 {
+			return nil, os.ErrPermission
+		}
+		return NewFileCheckpointStore(dir)
+	})
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint dir should not be created before use, err: %v", err)
+	}
+
+	key := task.CheckpointKey{TaskId: 1, Page: 1, ScheduleTime: 1000}
+	if err := store.Save(key, []byte("a")); err == nil {
+		t.Errorf("save should fail when the store can not be created")
+	}
+	if err := store.Save(key, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if cursor, err := store.Load(key); string(cursor) != "a" || err != nil || creates != 2 {
+		t.Errorf("store should be created once after the failure, cursor: %s, err: %v, creates: %d", cursor, err, creates)
+	}
+}
+
+// TestOpenApiCheckpointStore 测试通过OpenApi保存和查询检查点
+func TestOpenApiCheckpointStore(t *testing.T) {
+	checkpoints := make(map[int64][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var params bean.CheckpointParams
+		_ = json.NewDecoder(request.Body).Decode(&params)
+		switch request.URL.Path {
+		case apiCheckpointSave:
+			checkpoints[params.ScheduleTime] = params.Cursor
+		case apiCheckpointDelete:
+			delete(checkpoints, params.ScheduleTime)
+		case apiCheckpointGet:
+			if cursor, ok := checkpoints[params.ScheduleTime]; ok {
+				params.Cursor = cursor
+				_, _ = writer.Write([]byte(utils.ToJsonString(webresult.Success(params))))
+				return
+			}
+		}
+		_, _ = writer.Write([]byte(utils.ToJsonString(webresult.SUCCESS)))
+	}))
+	defer server.Close()
+	httpclients.Init(httpclients.Options{Timeout: time.Second})
+	GetOpenApiService().SetHost(server.URL)
+
+	store := NewOpenApiCheckpointStore(bean.ExecutorOptions{Tenant: "horace", AppName: "app"})
+	key := task.CheckpointKey{TaskId: 2, Page: 1, ScheduleTime: 3000}
+	if cursor, err := store.Load(key); cursor != nil || err != nil {
+		t.Errorf("missing checkpoint should be nil, cursor: %v, err: %v", cursor, err)
+	}
+	if err := store.Save(key, []byte{0, 1, 255}); err != nil {
+		t.Fatal(err)
+	}
+	if cursor, err := store.Load(key); string(cursor) != string([]byte{0, 1, 255}) || err != nil {
+		t.Errorf("unexpected cursor: %v, err: %v", cursor, err)
+	}
+	if err := store.Delete(key); err != nil || len(checkpoints) != 0 {
+		t.Errorf("checkpoint not deleted, err: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+// Created in 2026-10-18 20:45.
+// @author Horace
+
+import (
+	"github.com/horacedh/cronjob-executor/task"
+	"sync"
+	"time"
+)
+
+// 调度请求的去重状态
+const (
+	// dedupQueued 队列中
+	dedupQueued = 1
+	// dedupRunning 执行中
+	dedupRunning = 2
+	// dedupFinished 已完成，任务结果已经提交
+	dedupFinished = 3
+)
+
+// dedupEntry 去重记录
+type dedupEntry struct {
+	// state 调度请求的状态
+	state int
+	// result 已完成时的任务结果
+	result *task.TaskResult
+	// expireTime 已完成时的过期时间，毫秒，队列中和执行中的记录不会过期
+	expireTime int64
+}
+
+// dedupIndex 调度请求的去重索引，key为任务日志ID，覆盖队列中、执行中和最近完成的任务
+type dedupIndex struct {
+	mu sync.Mutex
+	// ttl 已完成的记录保留时间
+	ttl time.Duration
+	// entries 去重记录
+	entries map[int64]*dedupEntry
+	// lastSweepTime 最近一次清理过期记录的时间，毫秒
+	lastSweepTime int64
+}
+
+// add 添加队列中的记录，记录已经存在且没有过期时返回已有的记录
+func (index *dedupIndex) add(taskLogId int64) *dedupEntry {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	index.sweep(now)
+	if entry := index.entries[taskLogId]; entry != nil && (entry.state != dedupFinished || entry.expireTime > now) {
+		copied := *entry
+		return &copied
+	}
+	index.entries[taskLogId] = &dedupEntry{state: dedupQueued}
+	return nil
+}
+
+// running 标记为执行中，不在索引中的任务忽略
+func (index *dedupIndex) running(taskLogId int64) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if entry := index.entries[taskLogId]; entry != nil {
+		entry.state = dedupRunning
+	}
+}
+
+// finish 标记为已完成并缓存任务结果，不在索引中的任务忽略
+func (index *dedupIndex) finish(result *task.TaskResult) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if entry := index.entries[result.TaskLogId]; entry != nil {
+		entry.state = dedupFinished
+		entry.result = result
+		entry.expireTime = time.Now().Add(index.ttl).UnixMilli()
+	}
+}
+
+// sweep 清理过期的记录，最多每秒清理一次
+func (index *dedupIndex) sweep(now int64) {
+	if now-index.lastSweepTime < 1000 {
+		return
+	}
+	index.lastSweepTime = now
+	for taskLogId, entry := range index.entries {
+		if entry.state == dedupFinished && entry.expireTime <= now {
+			delete(index.entries, taskLogId)
+		}
+	}
+}
+
+// newDedupIndex 创建去重索引
+func newDedupIndex(ttl time.Duration) *dedupIndex {
+	return &dedupIndex{
+		ttl:     ttl,
+		entries: make(map[int64]*dedupEntry),
+	}
+}
@@ -4,17 +4,26 @@ package services
 // @author Horace
 
 import (
+	stdContext "context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	logger "github.com/cihub/seelog"
 	"github.com/emirpasic/gods/queues/priorityqueue"
 	godsutils "github.com/emirpasic/gods/utils"
 	"github.com/horacedh/cronjob-executor/bean"
 	"github.com/horacedh/cronjob-executor/context"
+	"github.com/horacedh/cronjob-executor/metrics"
 	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/tracing"
 	"github.com/horacedh/cronjob-executor/utils"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"reflect"
 	"runtime/debug"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,21 +39,141 @@ type DispatcherService interface {
 	Start(address string)
 	// AddTask 添加任务
 	AddTask(params *task.TaskParams) int
+	// Dispatch 添加调度器分发的任务，任务日志ID在队列中、执行中或者最近已完成时不会重复添加，返回队列大小和是否重复
+	Dispatch(params *task.TaskParams) (int, bool)
 	// getTask 线程安全的获取任务
 	getTask() *task.TaskParams
-	// invokeTask 执行任务
-	invokeTask(address string, params *task.TaskParams)
+	// invokeTask 执行任务，report为true时将任务结果发送给调度器
+	invokeTask(address string, params *task.TaskParams, report bool) *task.HandlerResult
+	// RunNow 在本执行器上立即执行任务，不经过调度器，同步返回任务处理结果
+	RunNow(address string, name string, options bean.RunNowOptions) (*task.HandlerResult, error)
+	// GetTaskOptions 获取已注册的任务配置，key为包路径+方法名
+	GetTaskOptions() map[string]*bean.TaskOptions
+	// GetQueuedTasks 获取队列中等待执行的任务，按照执行时间升序排序
+	GetQueuedTasks() []*QueuedTask
+	// GetRunningTasks 获取正在执行的任务，按照开始执行时间升序排序
+	GetRunningTasks() []*RunningTask
+	// GetQueueSize 获取队列中等待执行的任务数量
+	GetQueueSize() int
+	// GetRunningCount 获取正在执行的任务数量
+	GetRunningCount() int
+}
+
+// QueuedTask 队列中等待执行的任务
+type QueuedTask struct {
+	// Params 任务参数
+	Params *task.TaskParams `json:"params"`
+	// DueTime 到期执行时间
+	DueTime string `json:"dueTime"`
+	// RemainingTime 距离执行时间的剩余时间，毫秒，小于0表示已经到期
+	RemainingTime int64 `json:"remainingTime"`
+}
+
+// RunningTask 正在执行的任务
+type RunningTask struct {
+	// Params 任务参数
+	Params *task.TaskParams `json:"params"`
+	// StartTime 开始执行时间，毫秒
+	StartTime int64 `json:"startTime"`
+	// ElapsedTime 已执行的时间，毫秒
+	ElapsedTime int64 `json:"elapsedTime"`
 }
 
 // dispatcherServiceImpl 实现类
 type dispatcherServiceImpl struct {
 	mu sync.Mutex
+	// options 执行器配置
+	options bean.ExecutorOptions
 	// taskQueue 任务队列，按照执行时间升序排序
 	taskQueue *priorityqueue.Queue
-	// handlers 任务处理方法集合，key为包路径+方法名，value为方法的反射值
-	handlers map[string]*reflect.Value
-	// taskOptions 任务配置集合，key为包路径+方法名，value为任务配置
-	taskOptions map[string]*bean.TaskOptions
+	// registry 任务注册表，运行时可以添加、移除或者修改任务
+	registry TaskRegistry
+	// runningTasks 正在执行的任务，key为任务参数，value为执行状态
+	runningTasks sync.Map
+	// runningCount 正在执行的任务数量
+	runningCount atomic.Int64
+	// inflight 已经开始调度但还没有执行完成的任务，停机时等待
+	inflight sync.WaitGroup
+	// dedup 调度请求的去重索引
+	dedup *dedupIndex
+	// checkpoints 检查点存储，为空时处理方法不能保存检查点
+	checkpoints task.CheckpointStore
+}
+
+// runningState 正在执行的任务的状态
+type runningState struct {
+	// startTime 开始执行时间，毫秒
+	startTime int64
+	// ctx 任务的链路上下文，停机超时时提交的任务结果使用，不从params中读取，避免与执行任务的goroutine竞争
+	ctx stdContext.Context
+	// cancel 取消任务上下文
+	cancel stdContext.CancelCauseFunc
+	// reported 任务结果是否已经提交，停机超时时提前提交失败结果，处理方法返回后不再重复提交
+	reported atomic.Bool
+}
+
+// maxOutputSize 处理方法输出数据序列化后的最大长度，超过时不发送给调度器
+const maxOutputSize = 64 * 1024
+
+// errShutdownDeadline 停机超时，取消正在执行的任务
+var errShutdownDeadline = errors.New("executor shutdown deadline exceeded")
+
+// GetTaskOptions 获取已注册的任务配置
+func (dispatcherService *dispatcherServiceImpl) GetTaskOptions() map[string]*bean.TaskOptions {
+	return dispatcherService.registry.Options()
+}
+
+// GetQueuedTasks 获取队列中等待执行的任务
+func (dispatcherService *dispatcherServiceImpl) GetQueuedTasks() []*QueuedTask {
+	dispatcherService.mu.Lock()
+	values := dispatcherService.taskQueue.Values()
+	dispatcherService.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	queuedTasks := make([]*QueuedTask, 0, len(values))
+	for _, value := range values {
+		params := value.(*task.TaskParams)
+		queuedTasks = append(queuedTasks, &QueuedTask{
+			Params:        params,
+			DueTime:       utils.FormatTime(params.ExecutionTime),
+			RemainingTime: params.ExecutionTime - now,
+		})
+	}
+	sort.Slice(queuedTasks, func(i, j int) bool {
+		return queuedTasks[i].Params.ExecutionTime < queuedTasks[j].Params.ExecutionTime
+	})
+	return queuedTasks
+}
+
+// GetRunningTasks 获取正在执行的任务
+func (dispatcherService *dispatcherServiceImpl) GetRunningTasks() []*RunningTask {
+	now := time.Now().UnixMilli()
+	runningTasks := make([]*RunningTask, 0)
+	dispatcherService.runningTasks.Range(func(key, value any) bool {
+		startTime := value.(*runningState).startTime
+		runningTasks = append(runningTasks, &RunningTask{
+			Params:      key.(*task.TaskParams),
+			StartTime:   startTime,
+			ElapsedTime: now - startTime,
+		})
+		return true
+	})
+	sort.Slice(runningTasks, func(i, j int) bool {
+		return runningTasks[i].StartTime < runningTasks[j].StartTime
+	})
+	return runningTasks
+}
+
+// GetQueueSize 获取队列中等待执行的任务数量
+func (dispatcherService *dispatcherServiceImpl) GetQueueSize() int {
+	dispatcherService.mu.Lock()
+	defer dispatcherService.mu.Unlock()
+	return dispatcherService.taskQueue.Size()
+}
+
+// GetRunningCount 获取正在执行的任务数量
+func (dispatcherService *dispatcherServiceImpl) GetRunningCount() int {
+	return int(dispatcherService.runningCount.Load())
 }
 
 // getTask 线程安全的获取任务
@@ -52,6 +181,7 @@ func (dispatcherService *dispatcherServiceImpl) getTask() *task.TaskParams {
 	dispatcherService.mu.Lock()
 	defer dispatcherService.mu.Unlock()
 	taskParams, _ := dispatcherService.taskQueue.Dequeue()
+	metrics.DispatcherQueueSize.Set(float64(dispatcherService.taskQueue.Size()))
 	if taskParams == nil {
 		return nil
 	}
@@ -64,7 +194,7 @@ func (dispatcherService *dispatcherServiceImpl) Start(address string) {
 	go GetResultSendService().Start()
 
 	// 队列中还有元素，或者还没停机
-	for dispatcherService.taskQueue.Size() > 0 || !context.Shutdown.Load() {
+	for dispatcherService.GetQueueSize() > 0 || !context.Shutdown.Load() {
 		taskParams := dispatcherService.getTask()
 
 		// 如果队列为空，则休眠一段时间，等待任务到来
@@ -73,70 +203,187 @@ func (dispatcherService *dispatcherServiceImpl) Start(address string) {
 			continue
 		}
 
-		// 如果还没达到执行时间，重新添加到队列并休眠指定时间
+		// 如果还没达到执行时间，重新添加到队列并休眠，最多休眠200毫秒，避免错过新加入的更早的任务
 		var remaining = taskParams.ExecutionTime - time.Now().UnixMilli()
 		if remaining > 0 {
+			// 停机时不再等待未到期的任务，通知调度器取消，由调度器重新调度
+			if context.Shutdown.Load() {
+				dispatcherService.cancelTask(address, taskParams)
+				continue
+			}
 			dispatcherService.AddTask(taskParams)
-			time.Sleep(time.Duration(remaining)*time.Millisecond - 1)
+			time.Sleep(min(time.Duration(remaining)*time.Millisecond, time.Millisecond*200))
 			continue
 		}
 
 		// 执行任务
-		go dispatcherService.invokeTask(address, taskParams)
+		dispatcherService.inflight.Add(1)
+		go func() {
+			defer dispatcherService.inflight.Done()
+			dispatcherService.invokeTask(address, taskParams, true)
+		}()
 	}
 
-	time.Sleep(time.Second * 3)
+	// 等待正在执行的任务完成，超过停机截止时间后取消
+	dispatcherService.awaitRunningTasks(address, time.UnixMilli(context.ShutdownDeadline.Load()))
 	logger.Infof("dispatcher service stopped.")
 	context.DispatcherStopped.Store(true)
 }
 
+// cancelTask 停机时取消还没有到期的任务，通知调度器任务已取消
+func (dispatcherService *dispatcherServiceImpl) cancelTask(address string, params *task.TaskParams) {
+	logger.Warnf("executor is shutting down, cancel the task before the execution time, task:%s", utils.ToJsonString(params))
+	result := &task.TaskResult{
+		TaskLogId:    params.TaskLogId,
+		TaskId:       params.TaskId,
+		State:        task.EXECUTION_CANCEL,
+		FailedReason: "executor is shutting down before the execution time: " + utils.FormatTime(params.ExecutionTime),
+		Address:      address,
+	}
+	result.SetContext(params.Context())
+	dispatcherService.submitResult(result)
+}
+
+// awaitRunningTasks 等待正在执行的任务完成，超过截止时间后取消任务上下文，并提交失败结果
+func (dispatcherService *dispatcherServiceImpl) awaitRunningTasks(address string, deadline time.Time) {
+	if utils.WaitTimeout(&dispatcherService.inflight, time.Until(deadline)) {
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	dispatcherService.runningTasks.Range(func(key, value any) bool {
+		params := key.(*task.TaskParams)
+		state := value.(*runningState)
+		state.cancel(errShutdownDeadline)
+		if !state.reported.CompareAndSwap(false, true) {
+			return true
+		}
+
+		logger.Errorf("executor shutdown deadline exceeded, cancel the running task, task:%s", utils.ToJsonString(params))
+		result := &task.TaskResult{
+			TaskLogId:         params.TaskLogId,
+			TaskId:            params.TaskId,
+			State:             task.EXECUTION_FAILED,
+			FailedReason:      errShutdownDeadline.Error(),
+			RealExecutionTime: state.startTime,
+			ElapsedTime:       int(now - state.startTime),
+			Address:           address,
+		}
+		result.SetContext(state.ctx)
+		dispatcherService.submitResult(result)
+		return true
+	})
+}
+
 // AddTask 添加任务
 func (dispatcherService *dispatcherServiceImpl) AddTask(params *task.TaskParams) int {
 	dispatcherService.mu.Lock()
 	defer dispatcherService.mu.Unlock()
 
 	dispatcherService.taskQueue.Enqueue(params)
-	return dispatcherService.taskQueue.Size()
+	size := dispatcherService.taskQueue.Size()
+	metrics.DispatcherQueueSize.Set(float64(size))
+	return size
+}
+
+// Dispatch 添加调度器分发的任务，调度器超时重试时同一个任务日志ID只执行一次
+func (dispatcherService *dispatcherServiceImpl) Dispatch(params *task.TaskParams) (int, bool) {
+	entry := dispatcherService.dedup.add(params.TaskLogId)
+	if entry == nil {
+		return dispatcherService.AddTask(params), false
+	}
+
+	metrics.DuplicateDispatches.Inc()
+	logger.Warnf("received duplicate execute request, ignore the task, state:%d, params:%s", entry.state, utils.ToJsonString(params))
+
+	// 任务结果已经发送给调度器时重新发送，还在等待发送时不需要重复发送
+	if entry.state == dedupFinished && !GetResultSendService().HasPendingResult(params.TaskLogId) {
+		result := *entry.result
+		result.SetContext(params.Context())
+		GetResultSendService().AddResult(&result)
+	}
+	return dispatcherService.GetQueueSize(), true
+}
+
+// submitResult 提交任务结果，并记录到去重索引
+func (dispatcherService *dispatcherServiceImpl) submitResult(result *task.TaskResult) {
+	dispatcherService.dedup.finish(result)
+	GetResultSendService().AddResult(result)
+}
+
+// RunNow 在本执行器上立即执行任务，name可以是任务方法（包路径+方法名）或者任务名称
+func (dispatcherService *dispatcherServiceImpl) RunNow(address string, name string, options bean.RunNowOptions) (*task.HandlerResult, error) {
+	method, taskOptions := dispatcherService.registry.Find(name)
+	if taskOptions == nil {
+		return nil, errors.New("task not found: " + name)
+	}
+	if options.Report && options.TaskLogId == 0 {
+		return nil, errors.New("taskLogId is required when reporting the result to the scheduler")
+	}
+
+	now := time.Now().UnixMilli()
+	params := &task.TaskParams{
+		Page:          options.Page,
+		Total:         options.Total,
+		TaskLogId:     options.TaskLogId,
+		Method:        method,
+		ExeType:       task.ExeTypeManual,
+		Cron:          taskOptions.Cron,
+		ExecutionTime: now,
+		Params:        options.Params,
+	}
+	logger.Infof("run task now, report:%t, params:%s", options.Report, utils.ToJsonString(params))
+	return dispatcherService.invokeTask(address, params, options.Report), nil
 }
 
-// invokeTask 执行任务
-func (dispatcherService *dispatcherServiceImpl) invokeTask(address string, params *task.TaskParams) {
-	reflectValue := dispatcherService.handlers[params.Method]
+// invokeTask 执行任务，开启本地重试时失败后在本地重试，重试期间发送重试中的状态，只提交最终的任务结果
+func (dispatcherService *dispatcherServiceImpl) invokeTask(address string, params *task.TaskParams, report bool) (handlerResult *task.HandlerResult) {
+	// 记录任务在队列中的等待时间，从接收到调度请求开始计算
+	attributes := tracing.TaskAttributes(params.TaskLogId, params.TaskId, params.Method)
+	if params.ReceivedDispatcherTime > 0 {
+		_, queueSpan := tracing.Tracer().Start(params.Context(), tracing.SpanQueueWait,
+			trace.WithTimestamp(time.UnixMilli(params.ReceivedDispatcherTime)), trace.WithAttributes(attributes...))
+		queueSpan.End()
+	}
+
+	ctx, span := tracing.Tracer().Start(params.Context(), tracing.SpanInvoke, trace.WithAttributes(attributes...))
+
+	// 开始执行时获取处理方法和配置，之后任务被移除或者修改也不影响本次执行
+	reflectValue, options := dispatcherService.registry.Get(params.Method)
+
+	// 任务不存在，或者在队列中等待时已经被移除
 	if reflectValue == nil {
-		logger.Warnf("dispatch task error, target method is null, task:%s,", utils.ToJsonString(params))
-		GetResultSendService().AddResult(&task.TaskResult{
+		logger.Warnf("dispatch task error, target method is null or has been removed, task:%s,", utils.ToJsonString(params))
+		metrics.TaskResults.WithLabelValues(params.Method, metrics.ResultNotFound).Inc()
+		span.SetStatus(codes.Error, "target method not found")
+		span.End()
+		result := &task.TaskResult{
 			TaskLogId: params.TaskLogId,
 			TaskId:    params.TaskId,
 			State:     task.EXECUTION_FAILED_NOT_FOUND,
-		})
-		return
+		}
+		result.SetContext(ctx)
+		if report {
+			dispatcherService.submitResult(result)
+		}
+		return task.Failed("target method not found: " + params.Method)
 	}
 
+	// 停机超时时通过runningState取消任务上下文
+	taskContext, cancel := stdContext.WithCancelCause(ctx)
+	defer cancel(nil)
+	params.SetCheckpointStore(dispatcherService.checkpoints)
+
 	startTime := time.Now().UnixMilli()
-	var state = task.EXECUTION_SUCCESS
-	var failureReason string
-	var handlerResult *task.HandlerResult
+	running := &runningState{startTime: startTime, ctx: ctx, cancel: cancel}
+	if report {
+		dispatcherService.dedup.running(params.TaskLogId)
+	}
+	dispatcherService.runningTasks.Store(params, running)
+	dispatcherService.runningCount.Add(1)
 
-	// 如果任务执行发生异常
-	defer func() {
-		if r := recover(); r != nil {
-			logger.Errorf("cron job task handler exception, realExecutionTime:%s, executionTime:%s, task:%s, msg:%v",
-				utils.FormatTime(startTime), utils.FormatTime(params.ExecutionTime), utils.ToJsonString(params), r)
-			state = task.EXECUTION_FAILED
-			failureReason = fmt.Sprintf("%v\r\n\r\n%s", r, string(debug.Stack()))
-		}
-
-		endTime := time.Now().UnixMilli()
-		GetResultSendService().AddResult(&task.TaskResult{
-			TaskLogId:         params.TaskLogId,
-			TaskId:            params.TaskId,
-			State:             state,
-			FailedReason:      failureReason,
-			RealExecutionTime: startTime,
-			ElapsedTime:       int(endTime - startTime),
-			Address:           address,
-		})
-	}()
+	// 记录计划执行时间与实际执行时间之间的延迟
+	metrics.DispatchDelay.Observe(float64(max(startTime-params.ExecutionTime, 0)) / 1000)
 
 	// 检查执行延迟
 	//delayTime := startTime - params.ExecutionTime
@@ -158,35 +405,301 @@ func (dispatcherService *dispatcherServiceImpl) invokeTask(address string, param
 	//	}
 	//}
 
+	var outcome *handlerOutcome
+	var attempts []task.TaskAttempt
+	for attempt := 1; ; attempt++ {
+		attemptStartTime := time.Now().UnixMilli()
+		outcome = dispatcherService.callHandler(taskContext, reflectValue, params, options, attemptStartTime)
+		if options.LocalRetryCount > 0 {
+			attempts = append(attempts, task.TaskAttempt{
+				Attempt:      attempt,
+				State:        outcome.state,
+				FailedReason: outcome.failureReason,
+				StartTime:    attemptStartTime,
+				ElapsedTime:  int(time.Now().UnixMilli() - attemptStartTime),
+			})
+		}
+		if outcome.state == task.EXECUTION_SUCCESS || attempt > options.LocalRetryCount || !dispatcherService.retryable(taskContext, params, options, outcome) {
+			break
+		}
+
+		// 发送重试中的状态，调度器据此知道任务还在执行
+		interval := localRetryInterval(options, attempt)
+		logger.Warnf("cron job task failed, retry locally after %v, attempt:%d, localRetryCount:%d, params:%s",
+			interval, attempt, options.LocalRetryCount, utils.ToJsonString(params))
+		if report && !running.reported.Load() {
+			result := &task.TaskResult{
+				TaskLogId:         params.TaskLogId,
+				TaskId:            params.TaskId,
+				State:             task.EXECUTION_FAILED_RETRYING,
+				FailedReason:      outcome.failureReason,
+				RealExecutionTime: startTime,
+				ElapsedTime:       int(time.Now().UnixMilli() - startTime),
+				Address:           address,
+				Attempts:          append([]task.TaskAttempt(nil), attempts...),
+			}
+			result.SetContext(ctx)
+			GetResultSendService().AddResult(result)
+		}
+
+		// 等待重试间隔，任务被取消时不再重试
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-taskContext.Done():
+			timer.Stop()
+		}
+		if taskContext.Err() != nil {
+			break
+		}
+	}
+
+	endTime := time.Now().UnixMilli()
+	dispatcherService.runningTasks.Delete(params)
+	dispatcherService.runningCount.Add(-1)
+	metrics.HandlerDuration.WithLabelValues(params.Method).Observe(float64(endTime-startTime) / 1000)
+	metrics.TaskResults.WithLabelValues(params.Method, outcome.metricsResult).Inc()
+
+	span.SetAttributes(tracing.AttrState.Int(int(outcome.state)))
+	if outcome.state != task.EXECUTION_SUCCESS {
+		span.SetStatus(codes.Error, outcome.failureReason)
+	}
+	span.End()
+
+	// 发生异常或者返回值为空时，构造失败的处理结果返回
+	handlerResult = outcome.result
+	if handlerResult == nil {
+		handlerResult = task.Failed(outcome.failureReason)
+	}
+
+	// 执行成功后删除检查点，失败时保留，本地重试时从检查点继续执行
+	if outcome.state == task.EXECUTION_SUCCESS && params.HasCheckpoint() {
+		if err := params.ClearCheckpoint(); err != nil {
+			logger.Warnf("clear checkpoint failed, key:%s, err:%v", params.CheckpointKey(), err)
+		}
+	}
+
+	if report && !running.reported.CompareAndSwap(false, true) {
+		return handlerResult
+	}
+	result := &task.TaskResult{
+		TaskLogId:         params.TaskLogId,
+		TaskId:            params.TaskId,
+		State:             outcome.state,
+		FailedReason:      outcome.failureReason,
+		RealExecutionTime: startTime,
+		ElapsedTime:       int(endTime - startTime),
+		Address:           address,
+		Code:              handlerResult.Code,
+		Category:          handlerResult.Category,
+		Output:            marshalOutput(params, handlerResult.Output),
+		Metrics:           handlerResult.Metrics,
+		Counters:          handlerResult.Counters,
+		Attempts:          attempts,
+	}
+	result.SetContext(ctx)
+	if report {
+		dispatcherService.submitResult(result)
+	}
+
+	// 本地立即执行时不触发后续任务，本地触发的后续任务可以继续触发下一级任务，还会按照失败策略重试的失败不触发
+	if (report || params.ExeType == task.ExeTypeChain) && (result.State == task.EXECUTION_SUCCESS || dispatcherService.finalFailure(params, options, report)) {
+		dispatcherService.triggerChain(address, params, options, result)
+	}
+	return handlerResult
+}
+
+// finalFailure 失败后是否不再重试，结果不发送给调度器时没有重试，失败策略为丢弃时不会重试。
+// 调度器不下发重试次数，失败策略为重试时无法判断是否为最终失败，添加任务时已经拒绝这种配置，只有单机模式根据本地的重试次数判断
+func (dispatcherService *dispatcherServiceImpl) finalFailure(params *task.TaskParams, options *bean.TaskOptions, report bool) bool {
+	if !report || options.FailureStrategy != bean.FailureRetry {
+		return true
+	}
+	return dispatcherService.options.Standalone && params.RetryCount >= options.MaxRetryCount
+}
+
+// triggerChain 按照任务的最终执行结果触发后续任务，任务的输出数据作为后续任务的参数，分片任务的后续任务处理相同的分片
+func (dispatcherService *dispatcherServiceImpl) triggerChain(address string, params *task.TaskParams, options *bean.TaskOptions, result *task.TaskResult) {
+	targets := options.OnSuccess
+	if result.State != task.EXECUTION_SUCCESS {
+		targets = options.OnFailure
+	}
+	if len(targets) == 0 {
+		return
+	}
+	if context.Shutdown.Load() {
+		logger.Warnf("executor is shutting down, skip the chained tasks, targets:%v, params:%s", targets, utils.ToJsonString(params))
+		return
+	}
+
+	for _, target := range targets {
+		if options.ChainMode == bean.ChainScheduler {
+			GetOpenApiService().TriggerTask(bean.TaskTriggerParams{
+				Tenant:          dispatcherService.options.Tenant,
+				AppName:         dispatcherService.options.AppName,
+				Tag:             params.Tag,
+				Name:            target,
+				Params:          string(result.Output),
+				ParentTaskLogId: params.TaskLogId,
+				Page:            params.Page,
+				Total:           params.Total,
+			})
+			continue
+		}
+
+		method, targetOptions := dispatcherService.registry.Find(target)
+		if targetOptions == nil {
+			logger.Warnf("chained task not found, target:%s, params:%s", target, utils.ToJsonString(params))
+			continue
+		}
+		chainParams := &task.TaskParams{
+			Page:          max(params.Page, 1),
+			Total:         max(params.Total, 1),
+			Method:        method,
+			ExeType:       task.ExeTypeChain,
+			Cron:          targetOptions.Cron,
+			Tag:           params.Tag,
+			ExecutionTime: time.Now().UnixMilli(),
+			Params:        string(result.Output),
+		}
+		// 后续任务加入上游任务的链路，但是不受上游任务上下文取消的影响
+		chainParams.SetContext(trace.ContextWithSpanContext(stdContext.Background(), trace.SpanContextFromContext(result.Context())))
+		logger.Infof("trigger chained task, parent:%s, state:%d, params:%s", params.Method, result.State, utils.ToJsonString(chainParams))
+
+		dispatcherService.inflight.Add(1)
+		go func() {
+			defer dispatcherService.inflight.Done()
+			dispatcherService.invokeTask(address, chainParams, false)
+		}()
+	}
+}
+
+// marshalOutput 序列化处理方法的输出数据，序列化失败或者超过最大长度时丢弃
+func marshalOutput(params *task.TaskParams, output any) json.RawMessage {
+	if output == nil {
+		return nil
+	}
+	data, err := json.Marshal(output)
+	if err != nil {
+		logger.Warnf("marshal handler output failed, output is discarded, params:%s, err:%v", utils.ToJsonString(params), err)
+		return nil
+	}
+	if len(data) > maxOutputSize {
+		logger.Warnf("handler output is too large, output is discarded, size:%d, params:%s", len(data), utils.ToJsonString(params))
+		return nil
+	}
+	return data
+}
+
+// handlerOutcome 一次调用处理方法的结果
+type handlerOutcome struct {
+	// result 处理方法的返回值，发生异常或者返回空时为nil
+	result *task.HandlerResult
+	// state 任务状态
+	state task.TaskLogState
+	// failureReason 失败原因
+	failureReason string
+	// metricsResult 指标中的结果标签
+	metricsResult string
+}
+
+// callHandler 调用一次处理方法，每次调用单独计算超时时间
+func (dispatcherService *dispatcherServiceImpl) callHandler(ctx stdContext.Context, reflectValue *reflect.Value, params *task.TaskParams, options *bean.TaskOptions, startTime int64) (outcome *handlerOutcome) {
+	outcome = &handlerOutcome{state: task.EXECUTION_SUCCESS, metricsResult: metrics.ResultSuccess}
+
+	// 任务上下文在超时时间后取消，处理方法可以通过params.Context()感知。
+	// params只在执行任务的goroutine中读写上下文，其他goroutine使用runningState中的上下文
+	handlerContext := ctx
+	if options.Timeout > 0 {
+		var cancel stdContext.CancelFunc
+		handlerContext, cancel = stdContext.WithTimeout(ctx, time.Duration(options.Timeout)*time.Millisecond)
+		defer cancel()
+	}
+	params.SetContext(handlerContext)
+
+	// 如果任务执行发生异常
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("cron job task handler exception, realExecutionTime:%s, executionTime:%s, task:%s, msg:%v",
+				utils.FormatTime(startTime), utils.FormatTime(params.ExecutionTime), utils.ToJsonString(params), r)
+			outcome.result = nil
+			outcome.state = task.EXECUTION_FAILED
+			outcome.failureReason = fmt.Sprintf("%v\r\n\r\n%s", r, string(debug.Stack()))
+			outcome.metricsResult = metrics.ResultPanic
+		}
+	}()
+
 	// 执行目标方法
 	results := reflectValue.Call([]reflect.Value{reflect.ValueOf(params)})
 	if len(results) == 0 || results[0].IsNil() {
-		state = task.EXECUTION_FAILED
-		failureReason = fmt.Sprintf("result is null, please check the return value of the method: %s", params.Method)
+		outcome.state = task.EXECUTION_FAILED
+		outcome.failureReason = fmt.Sprintf("result is null, please check the return value of the method: %s", params.Method)
+		outcome.metricsResult = metrics.ResultFailed
 		logger.Errorf("cron job task handler failed, result is nil, realExecutionTime:%s, executionTime:%s, params:%s",
 			utils.FormatTime(startTime), utils.FormatTime(params.ExecutionTime), utils.ToJsonString(params))
-		return
+		return outcome
 	}
 
-	handlerResult = results[0].Interface().(*task.HandlerResult)
-	if !handlerResult.IsSuccess() {
-		state = task.EXECUTION_FAILED
-		failureReason = fmt.Sprintf("cron job task handler failed, code:%d, msg:%s", handlerResult.Code, handlerResult.Msg)
+	outcome.result = results[0].Interface().(*task.HandlerResult)
+	if outcome.result.IsSuccess() && outcome.result.Category == task.CategorySkipped {
+		outcome.metricsResult = metrics.ResultSkipped
+	} else if !outcome.result.IsSuccess() {
+		outcome.state = task.EXECUTION_FAILED
+		outcome.failureReason = fmt.Sprintf("cron job task handler failed, code:%d, msg:%s", outcome.result.Code, outcome.result.Msg)
+		outcome.metricsResult = metrics.ResultFailed
 		logger.Errorf("cron job task handler failed, code:%d, msg:%s, realExecutionTime:%s, executionTime:%s, params:%s",
-			handlerResult.Code, handlerResult.Msg,
+			outcome.result.Code, outcome.result.Msg,
 			utils.FormatTime(startTime), utils.FormatTime(params.ExecutionTime), utils.ToJsonString(params))
 	}
+	return outcome
+}
+
+// retryable 判断失败是否可以在本地重试，任务被取消或者正在停机时不重试，其他情况交给错误分类器判断
+func (dispatcherService *dispatcherServiceImpl) retryable(ctx stdContext.Context, params *task.TaskParams, options *bean.TaskOptions, outcome *handlerOutcome) (retryable bool) {
+	if ctx.Err() != nil || context.Shutdown.Load() {
+		return false
+	}
+	if options.LocalRetryClassifier == nil {
+		return outcome.result == nil || outcome.result.Category != task.CategoryPermanent
+	}
 
+	// 错误分类器发生异常时不重试
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("local retry classifier exception, params:%s, msg:%v", utils.ToJsonString(params), r)
+			retryable = false
+		}
+	}()
+	result := outcome.result
+	if result == nil {
+		result = task.Failed(outcome.failureReason)
+	}
+	return options.LocalRetryClassifier(params, result)
+}
+
+// localRetryInterval 计算第attempt次失败后的重试间隔时间，按照退避倍数递增，不超过最大间隔时间
+func localRetryInterval(options *bean.TaskOptions, attempt int) time.Duration {
+	interval := time.Duration(options.LocalRetryInterval) * time.Millisecond
+	maxInterval := time.Duration(options.LocalRetryMaxInterval) * time.Millisecond
+	for i := 1; i < attempt && interval < maxInterval; i++ {
+		interval *= time.Duration(max(options.LocalRetryMultiplier, 1))
+	}
+	if maxInterval > 0 {
+		interval = min(interval, maxInterval)
+	}
+	return interval
 }
 
 // InitDispatcherService 初始化
-func InitDispatcherService(handlers map[string]*reflect.Value, taskOptions map[string]*bean.TaskOptions) DispatcherService {
+func InitDispatcherService(options bean.ExecutorOptions, registry TaskRegistry, checkpoints task.CheckpointStore) DispatcherService {
 	dispatcherServiceOnce.Do(func() {
 		dispatcherService = &dispatcherServiceImpl{
 			mu:          sync.Mutex{},
+			options:     options,
 			taskQueue:   priorityqueue.NewWith(taskComparator),
-			handlers:    handlers,
-			taskOptions: taskOptions,
+			registry:    registry,
+			dedup:       newDedupIndex(time.Duration(options.DedupTTL) * time.Millisecond),
+			checkpoints: checkpoints,
 		}
 	})
 	return dispatcherService
@@ -0,0 +1,602 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/emirpasic/gods/queues/priorityqueue"
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/httpclients"
+	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/tracing"
+	"github.com/horacedh/cronjob-executor/utils"
+	"github.com/horacedh/cronjob-executor/webresult"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-18 11:40.
+// @author Horace
+
+// testAddress 测试用的执行器地址
+const testAddress = "127.0.0.1:8527"
+
+// newTestDispatcher 创建测试用的分发服务，使用指定的任务注册表和独立的去重索引，测试结束时等待本地触发的任务执行完成
+func newTestDispatcher(t *testing.T, registry TaskRegistry) *dispatcherServiceImpl {
+	t.Helper()
+	service := &dispatcherServiceImpl{
+		taskQueue: priorityqueue.NewWith(taskComparator),
+		registry:  registry,
+		dedup:     newDedupIndex(time.Minute),
+	}
+	t.Cleanup(service.inflight.Wait)
+	return service
+}
+
+// drainResults 清空待发送的任务结果，测试结束时再次清空，避免影响其他测试
+func drainResults(t *testing.T) *resultSendServiceImpl {
+	t.Helper()
+	resultSendService := GetResultSendService().(*resultSendServiceImpl)
+	drain := func() {
+		for takeResult(resultSendService) != nil {
+		}
+	}
+	drain()
+	t.Cleanup(drain)
+	return resultSendService
+}
+
+// takeResult 取出一个待发送的任务结果，并标记为发送完成
+func takeResult(resultSendService *resultSendServiceImpl) *task.TaskResult {
+	result := resultSendService.getTaskResult()
+	if result != nil {
+		resultSendService.sending.Add(-1)
+	}
+	return result
+}
+
+// TestInvokeTaskTracing 测试调度、执行、发送结果的链路追踪
+func TestInvokeTaskTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracing.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	// 模拟调度器传递的traceparent
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	params := &task.TaskParams{TaskLogId: 1001, TaskId: 1, Method: "demo", ReceivedDispatcherTime: time.Now().UnixMilli()}
+	params.SetContext(tracing.Extract(context.Background(), header))
+
+	var handlerSpanContext trace.SpanContext
+	handler := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		handlerSpanContext = trace.SpanContextFromContext(params.Context())
+		return task.Success()
+	})
+	registry := NewTaskRegistry()
+	registry.Put("demo", &handler, &bean.TaskOptions{Name: "demo"})
+	service := newTestDispatcher(t, registry)
+	resultSendService := drainResults(t)
+	service.invokeTask(testAddress, params, true)
+
+	if handlerSpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("handler context not joined to the dispatch trace, traceId: %s", handlerSpanContext.TraceID())
+	}
+
+	// 发送结果时需要传递traceparent
+	var receivedTraceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		receivedTraceparent = request.Header.Get("traceparent")
+		_, _ = writer.Write([]byte(utils.ToJsonString(webresult.SUCCESS)))
+	}))
+	defer server.Close()
+	httpclients.Init(httpclients.Options{Timeout: time.Second})
+	GetOpenApiService().SetHost(server.URL)
+
+	result := takeResult(resultSendService)
+	if result == nil || result.TaskLogId != params.TaskLogId {
+		t.Fatalf("task result not found, result: %v", result)
+	}
+	if !GetOpenApiService().SendTaskResult(result) {
+		t.Fatalf("send task result failed")
+	}
+	if receivedTraceparent == "" {
+		t.Fatalf("traceparent header not injected")
+	}
+
+	spans := exporter.GetSpans()
+	names := make(map[string]bool)
+	for _, span := range spans {
+		names[span.Name] = true
+		if span.SpanContext.TraceID() != handlerSpanContext.TraceID() {
+			t.Errorf("span %s not in the dispatch trace", span.Name)
+		}
+		linked := false
+		for _, attribute := range span.Attributes {
+			if attribute.Key == tracing.AttrTaskLogId && attribute.Value.AsInt64() == params.TaskLogId {
+				linked = true
+			}
+		}
+		if !linked {
+			t.Errorf("span %s not linked by taskLogId", span.Name)
+		}
+	}
+	for _, name := range []string{tracing.SpanQueueWait, tracing.SpanInvoke, tracing.SpanSendResult} {
+		if !names[name] {
+			t.Errorf("span %s not exported, spans: %v", name, names)
+		}
+	}
+}
+
+// TestRunNow 测试本地立即执行任务
+func TestRunNow(t *testing.T) {
+	var received *task.TaskParams
+	var hasDeadline bool
+	handler := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		received = params
+		_, hasDeadline = params.Context().Deadline()
+		return task.Failed("manual failed")
+	})
+	registry := NewTaskRegistry()
+	registry.Put("app/demo.Handle", &handler, &bean.TaskOptions{Name: "demo", Timeout: 1000})
+	service := newTestDispatcher(t, registry)
+
+	pending := len(GetResultSendService().GetPendingResults())
+	result, err := service.RunNow(testAddress, "demo", bean.RunNowOptions{Params: "p", Page: 2, Total: 3})
+	if err != nil {
+		t.Fatalf("run now failed, err: %v", err)
+	}
+	if result.IsSuccess() || result.Msg != "manual failed" {
+		t.Errorf("unexpected handler result: %v", result)
+	}
+	if received.ExeType != task.ExeTypeManual || received.Params != "p" || received.Page != 2 || received.Total != 3 {
+		t.Errorf("unexpected task params: %v", received)
+	}
+	if !hasDeadline {
+		t.Errorf("handler context has no deadline")
+	}
+	if len(GetResultSendService().GetPendingResults()) != pending {
+		t.Errorf("task result should not be reported")
+	}
+
+	if _, err = service.RunNow(testAddress, "missing", bean.RunNowOptions{}); err == nil {
+		t.Errorf("run now of a missing task should fail")
+	}
+	received = nil
+	if _, err = service.RunNow(testAddress, "demo", bean.RunNowOptions{Report: true}); err == nil || received != nil {
+		t.Errorf("reporting without a task log id should be rejected before running, err: %v", err)
+	}
+}
+
+// TestRemoveTaskInFlight 测试任务执行过程中被移除，本次执行正常完成，之后的执行返回任务不存在
+func TestRemoveTaskInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		close(started)
+		<-release
+		return task.Success()
+	})
+	registry := NewTaskRegistry()
+	registry.Put("app/removed.Handle", &handler, &bean.TaskOptions{Name: "removed", Timeout: 1000})
+	service := newTestDispatcher(t, registry)
+
+	done := make(chan *task.HandlerResult)
+	go func() {
+		done <- service.invokeTask(testAddress, &task.TaskParams{TaskLogId: 3001, Method: "app/removed.Handle"}, false)
+	}()
+	<-started
+	if !registry.Remove("app/removed.Handle") {
+		t.Fatalf("remove task failed")
+	}
+	close(release)
+	if result := <-done; !result.IsSuccess() {
+		t.Errorf("in-flight run should complete, result: %v", result)
+	}
+
+	if result := service.invokeTask(testAddress, &task.TaskParams{TaskLogId: 3002, Method: "app/removed.Handle"}, false); result.IsSuccess() {
+		t.Errorf("run after removal should fail, result: %v", result)
+	}
+	if len(service.GetTaskOptions()) != 0 || len(service.GetRunningTasks()) != 0 {
+		t.Errorf("removed task should not be listed")
+	}
+}
+
+// TestShutdownDeadline 测试停机时取消未到期的任务，超过截止时间后取消正在执行的任务并提交失败结果
+func TestShutdownDeadline(t *testing.T) {
+	cooperative := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		<-params.Context().Done()
+		return task.Failed(context.Cause(params.Context()).Error())
+	})
+	stubborn := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		time.Sleep(time.Millisecond * 500)
+		return task.Success()
+	})
+	registry := NewTaskRegistry()
+	registry.Put("app/cooperative.Handle", &cooperative, &bean.TaskOptions{Name: "cooperative"})
+	registry.Put("app/stubborn.Handle", &stubborn, &bean.TaskOptions{Name: "stubborn"})
+	service := newTestDispatcher(t, registry)
+
+	resultSendService := drainResults(t)
+
+	for index, method := range []string{"app/cooperative.Handle", "app/stubborn.Handle"} {
+		params := &task.TaskParams{TaskLogId: int64(5001 + index), Method: method}
+		service.inflight.Add(1)
+		go func() {
+			defer service.inflight.Done()
+			service.invokeTask(testAddress, params, true)
+		}()
+	}
+	service.cancelTask(testAddress, &task.TaskParams{TaskLogId: 5003, ExecutionTime: time.Now().Add(time.Hour).UnixMilli()})
+
+	for service.GetRunningCount() < 2 {
+		time.Sleep(time.Millisecond * 10)
+	}
+	start := time.Now()
+	service.awaitRunningTasks(testAddress, start.Add(time.Millisecond*200))
+	if elapsed := time.Since(start); elapsed < time.Millisecond*150 || elapsed > time.Millisecond*400 {
+		t.Errorf("await running tasks should stop at the deadline, elapsed: %s", elapsed)
+	}
+	service.inflight.Wait()
+
+	states := make(map[int64]task.TaskLogState)
+	for result := takeResult(resultSendService); result != nil; result = takeResult(resultSendService) {
+		if _, ok := states[result.TaskLogId]; ok {
+			t.Errorf("duplicate result, taskLogId: %d", result.TaskLogId)
+		}
+		states[result.TaskLogId] = result.State
+	}
+	expected := map[int64]task.TaskLogState{5001: task.EXECUTION_FAILED, 5002: task.EXECUTION_FAILED, 5003: task.EXECUTION_CANCEL}
+	if !reflect.DeepEqual(states, expected) {
+		t.Errorf("unexpected result states: %v", states)
+	}
+}
+
+// TestDispatchDedup 测试调度器重复分发同一个任务日志ID时只执行一次，已发送的结果重新发送
+func TestDispatchDedup(t *testing.T) {
+	var count int
+	handler := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		count++
+		return task.Success()
+	})
+	registry := NewTaskRegistry()
+	registry.Put("app/dedup.Handle", &handler, &bean.TaskOptions{Name: "dedup"})
+	service := newTestDispatcher(t, registry)
+	service.dedup = newDedupIndex(time.Millisecond * 200)
+
+	resultSendService := drainResults(t)
+
+	params := task.TaskParams{TaskLogId: 6001, Method: "app/dedup.Handle"}
+	retry := params
+	if _, duplicate := service.Dispatch(&params); duplicate {
+		t.Fatalf("first dispatch should not be duplicate")
+	}
+	if size, duplicate := service.Dispatch(&retry); !duplicate || size != 1 {
+		t.Fatalf("queued task should be duplicate, size: %d", size)
+	}
+
+	service.invokeTask(testAddress, service.getTask(), true)
+	if _, duplicate := service.Dispatch(&retry); !duplicate || len(resultSendService.GetPendingResults()) != 1 {
+		t.Errorf("pending result should not be sent twice, results: %v", resultSendService.GetPendingResults())
+	}
+
+	// 结果已经发送后，重复的调度请求重新发送缓存的结果
+	if result := takeResult(resultSendService); result == nil || result.State != task.EXECUTION_SUCCESS {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	if _, duplicate := service.Dispatch(&retry); !duplicate {
+		t.Errorf("finished task should be duplicate")
+	}
+	if result := takeResult(resultSendService); result == nil || result.TaskLogId != 6001 || result.State != task.EXECUTION_SUCCESS {
+		t.Errorf("cached result not re-sent, result: %v", result)
+	}
+	if count != 1 || service.GetQueueSize() != 0 {
+		t.Errorf("task should only run once, count: %d", count)
+	}
+
+	// 超过保留时间后可以再次执行
+	time.Sleep(time.Millisecond * 250)
+	if _, duplicate := service.Dispatch(&retry); duplicate {
+		t.Errorf("expired entry should not be duplicate")
+	}
+}
+
+// TestCheckpointResume 测试任务失败后保留检查点，同一个调度时间槽的重试从检查点继续执行，成功后删除检查点
+func TestCheckpointResume(t *testing.T) {
+	store, err := NewFileCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var processed []int
+	handler := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		cursor, err := params.LoadCheckpoint()
+		if err != nil {
+			return task.Failed(err.Error())
+		}
+		start := 0
+		if cursor != nil {
+			start = int(cursor[0])
+		}
+		for i := start; i < 5; i++ {
+			// 第一次执行处理到第3条数据时失败
+			if i == 3 && cursor == nil {
+				return task.Failed("interrupted")
+			}
+			processed = append(processed, i)
+			if err := params.SaveCheckpoint([]byte{byte(i + 1)}); err != nil {
+				return task.Failed(err.Error())
+			}
+		}
+		return task.Success()
+	})
+	registry := NewTaskRegistry()
+	registry.Put("app/checkpoint.Handle", &handler, &bean.TaskOptions{Name: "checkpoint"})
+	service := newTestDispatcher(t, registry)
+	service.checkpoints = store
+
+	params := &task.TaskParams{TaskLogId: 7001, TaskId: 7, Page: 1, Total: 1, Method: "app/checkpoint.Handle", ExecutionTime: 1000, ScheduleTime: 1000}
+	if result := service.invokeTask(testAddress, params, false); result.IsSuccess() {
+		t.Fatalf("first run should fail")
+	}
+	if cursor, _ := store.Load(params.CheckpointKey()); len(cursor) != 1 || cursor[0] != 3 {
+		t.Fatalf("checkpoint not kept after failure, cursor: %v", cursor)
+	}
+
+	// 重试的执行时间不同，调度时间槽相同
+	retry := &task.TaskParams{TaskLogId: 7002, TaskId: 7, Page: 1, Total: 1, Method: "app/checkpoint.Handle", ExecutionTime: 6000, ScheduleTime: 1000}
+	if result := service.invokeTask(testAddress, retry, false); !result.IsSuccess() {
+		t.Fatalf("retry should succeed, result: %v", result)
+	}
+	if !reflect.DeepEqual(processed, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("retry should resume from checkpoint, processed: %v", processed)
+	}
+	if cursor, _ := store.Load(params.CheckpointKey()); cursor != nil {
+		t.Errorf("checkpoint should be deleted after success, cursor: %v", cursor)
+	}
+}
+
+// TestLocalRetry 测试本地重试，重试期间发送重试中的状态，最终结果记录每一次执行，错误分类器返回false时不重试
+func TestLocalRetry(t *testing.T) {
+	var count int
+	handler := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		count++
+		switch {
+		case params.Params == "permanent":
+			return &task.HandlerResult{Code: 2, Msg: "permanent"}
+		case count < 3:
+			panic("temporary")
+		}
+		return task.Success()
+	})
+	registry := NewTaskRegistry()
+	registry.Put("app/retry.Handle", &handler, &bean.TaskOptions{
+		Name:                  "retry",
+		Timeout:               1000,
+		LocalRetryCount:       3,
+		LocalRetryInterval:    10,
+		LocalRetryMultiplier:  2,
+		LocalRetryMaxInterval: 15,
+		LocalRetryClassifier: func(params *task.TaskParams, result *task.HandlerResult) bool {
+			return result.Code != 2
+		},
+	})
+	service := newTestDispatcher(t, registry)
+
+	resultSendService := drainResults(t)
+
+	if result := service.invokeTask(testAddress, &task.TaskParams{TaskLogId: 8001, Method: "app/retry.Handle"}, true); !result.IsSuccess() || count != 3 {
+		t.Fatalf("task should succeed after local retries, count: %d, result: %v", count, result)
+	}
+	var states []task.TaskLogState
+	var final *task.TaskResult
+	for result := takeResult(resultSendService); result != nil; result = takeResult(resultSendService) {
+		states = append(states, result.State)
+		final = result
+	}
+	expected := []task.TaskLogState{task.EXECUTION_FAILED_RETRYING, task.EXECUTION_FAILED_RETRYING, task.EXECUTION_SUCCESS}
+	if !reflect.DeepEqual(states, expected) {
+		t.Fatalf("unexpected result states: %v", states)
+	}
+	if len(final.Attempts) != 3 || final.Attempts[0].State != task.EXECUTION_FAILED || final.Attempts[2].State != task.EXECUTION_SUCCESS {
+		t.Errorf("attempts not recorded, attempts: %+v", final.Attempts)
+	}
+
+	// 不可重试的失败直接提交最终结果
+	count = 0
+	service.invokeTask(testAddress, &task.TaskParams{TaskLogId: 8002, Method: "app/retry.Handle", Params: "permanent"}, true)
+	result := takeResult(resultSendService)
+	if count != 1 || result == nil || result.State != task.EXECUTION_FAILED || len(result.Attempts) != 1 {
+		t.Errorf("permanent failure should not be retried, count: %d, result: %+v", count, result)
+	}
+
+	_, options := registry.Get("app/retry.Handle")
+	for attempt, interval := range []time.Duration{10, 15, 15} {
+		if actual := localRetryInterval(options, attempt+1); actual != interval*time.Millisecond {
+			t.Errorf("unexpected interval of attempt %d: %v", attempt+1, actual)
+		}
+	}
+}
+
+// TestStructuredResult 测试处理结果的输出数据、计数和错误分类发送给调度器，不可重试的错误不在本地重试
+func TestStructuredResult(t *testing.T) {
+	var count int
+	handler := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		count++
+		if params.Params == "empty" {
+			return task.Skipped("no orders").WithCounter("orders", 0)
+		}
+		return task.FromError(task.Permanent(errors.New("invalid order"))).WithOutput(map[string]string{"order": "A1"})
+	})
+	registry := NewTaskRegistry()
+	registry.Put("app/structured.Handle", &handler, &bean.TaskOptions{Name: "structured", LocalRetryCount: 3, LocalRetryInterval: 10})
+	service := newTestDispatcher(t, registry)
+
+	resultSendService := drainResults(t)
+
+	service.invokeTask(testAddress, &task.TaskParams{TaskLogId: 9001, Method: "app/structured.Handle", Params: "empty"}, true)
+	result := takeResult(resultSendService)
+	if result == nil || result.State != task.EXECUTION_SUCCESS || result.Category != task.CategorySkipped {
+		t.Fatalf("skipped result not reported, result: %+v", result)
+	}
+	if counter, ok := result.Counters["orders"]; !ok || counter != 0 {
+		t.Errorf("counters not reported, result: %+v", result)
+	}
+
+	count = 0
+	service.invokeTask(testAddress, &task.TaskParams{TaskLogId: 9002, Method: "app/structured.Handle"}, true)
+	result = takeResult(resultSendService)
+	if count != 1 || result == nil || result.State != task.EXECUTION_FAILED || result.Category != task.CategoryPermanent || result.Code != 1 {
+		t.Fatalf("permanent failure should be reported without retry, count: %d, result: %+v", count, result)
+	}
+	if string(result.Output) != `{"order":"A1"}` {
+		t.Errorf("output not reported, output: %s", result.Output)
+	}
+	if body := utils.ToJsonString(result); !strings.Contains(body, `"category":"permanent"`) || !strings.Contains(body, `"output":{"order":"A1"}`) {
+		t.Errorf("unexpected result body: %s", body)
+	}
+}
+
+// TestTaskChain 测试任务成功后在本地触发后续任务，输出数据作为后续任务的参数，失败后通过调度器触发后续任务
+func TestTaskChain(t *testing.T) {
+	var triggered bean.TaskTriggerParams
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == apiTaskTrigger {
+			_ = json.NewDecoder(request.Body).Decode(&triggered)
+		}
+		_, _ = writer.Write([]byte(utils.ToJsonString(webresult.SUCCESS)))
+	}))
+	defer server.Close()
+	httpclients.Init(httpclients.Options{Timeout: time.Second})
+	GetOpenApiService().SetHost(server.URL)
+
+	received := make(chan *task.TaskParams, 2)
+	upstream := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		if params.Params == "fail" {
+			return task.Failed("failed").WithOutput("retry later")
+		}
+		return task.Success().WithOutput(map[string]int{"orders": 3})
+	})
+	downstream := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		received <- params
+		return task.Success()
+	})
+	registry := NewTaskRegistry()
+	registry.Put("app/upstream.Handle", &upstream, &bean.TaskOptions{Name: "upstream", OnSuccess: []string{"downstream"}, OnFailure: []string{"remote"}})
+	registry.Put("app/downstream.Handle", &downstream, &bean.TaskOptions{Name: "downstream"})
+	service := newTestDispatcher(t, registry)
+	service.options = bean.ExecutorOptions{Tenant: "horace", AppName: "app"}
+
+	drainResults(t)
+
+	// 本地立即执行时不触发后续任务
+	service.invokeTask(testAddress, &task.TaskParams{Method: "app/upstream.Handle"}, false)
+	service.invokeTask(testAddress, &task.TaskParams{TaskLogId: 10001, Method: "app/upstream.Handle", Tag: "common"}, true)
+	select {
+	case params := <-received:
+		if params.Params != `{"orders":3}` || params.ExeType != task.ExeTypeChain || params.Method != "app/downstream.Handle" {
+			t.Errorf("unexpected chained params: %s", utils.ToJsonString(params))
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("chained task not triggered")
+	}
+	service.inflight.Wait()
+	if len(received) != 0 {
+		t.Errorf("manual run should not trigger chained tasks")
+	}
+
+	// 切换为通过调度器触发
+	_, options := registry.Get("app/upstream.Handle")
+	schedulerOptions := *options
+	schedulerOptions.ChainMode = bean.ChainScheduler
+	registry.Put("app/upstream.Handle", &upstream, &schedulerOptions)
+	service.invokeTask(testAddress, &task.TaskParams{TaskLogId: 10002, Method: "app/upstream.Handle", Tag: "common", Params: "fail"}, true)
+	if triggered.Name != "remote" || triggered.Params != `"retry later"` || triggered.ParentTaskLogId != 10002 || triggered.AppName != "app" || triggered.Tenant != "horace" {
+		t.Errorf("scheduler trigger not requested, params: %+v", triggered)
+	}
+}
+
+// TestTaskChainFinalFailure 测试调度器下发的失败任务在失败策略为丢弃时触发一次后续任务，失败策略为重试时只有单机模式在达到最大重试次数后触发，
+// 分片任务每个分片触发处理相同分片的后续任务
+func TestTaskChainFinalFailure(t *testing.T) {
+	received := make(chan *task.TaskParams, 10)
+	upstream := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		if params.Params == "fail" {
+			return task.Failed("failed")
+		}
+		return task.Success()
+	})
+	downstream := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult {
+		received <- params
+		return task.Success()
+	})
+	registry := NewTaskRegistry()
+	registry.Put("app/retried.Handle", &upstream, &bean.TaskOptions{Name: "retried", FailureStrategy: bean.FailureRetry, MaxRetryCount: 2, OnSuccess: []string{"downstream"}, OnFailure: []string{"downstream"}})
+	registry.Put("app/discarded.Handle", &upstream, &bean.TaskOptions{Name: "discarded", FailureStrategy: bean.FailureDiscard, MaxRetryCount: 2, OnFailure: []string{"downstream"}})
+	registry.Put("app/downstream.Handle", &downstream, &bean.TaskOptions{Name: "downstream"})
+	service := newTestDispatcher(t, registry)
+	drainResults(t)
+
+	triggered := func() []*task.TaskParams {
+		service.inflight.Wait()
+		var triggered []*task.TaskParams
+		for len(received) > 0 {
+			triggered = append(triggered, <-received)
+		}
+		return triggered
+	}
+
+	// 调度器下发的任务参数，只有调度器实际发送的字段，没有重试次数
+	dispatch := func(body string) *task.TaskParams {
+		params := &task.TaskParams{}
+		if err := json.Unmarshal([]byte(body), params); err != nil {
+			t.Fatalf("unmarshal dispatch body failed, err: %v", err)
+		}
+		return params
+	}
+	service.invokeTask(testAddress, dispatch(`{"taskLogId":11001,"taskId":1,"method":"app/discarded.Handle","exeType":0,"cron":"0 0 * * * ?","tag":"common","executionTime":1760000000000,"params":"fail","retryCount":9}`), true)
+	if count := len(triggered()); count != 1 {
+		t.Errorf("discarded failure dispatched by the scheduler is final, triggered: %d", count)
+	}
+	for i := 0; i < 3; i++ {
+		service.invokeTask(testAddress, dispatch(fmt.Sprintf(`{"taskLogId":%d,"method":"app/retried.Handle","params":"fail"}`, 11002+i)), true)
+	}
+	if count := len(triggered()); count != 0 {
+		t.Errorf("failure retried by the scheduler can not be told final, triggered: %d", count)
+	}
+
+	// 单机模式根据本地的重试次数判断，达到最大重试次数后触发一次
+	service.options.Standalone = true
+	for retryCount := 0; retryCount <= 2; retryCount++ {
+		service.invokeTask(testAddress, &task.TaskParams{TaskLogId: int64(11005 + retryCount), Method: "app/retried.Handle", Params: "fail", RetryCount: retryCount}, true)
+		expected := 0
+		if retryCount == 2 {
+			expected = 1
+		}
+		if count := len(triggered()); count != expected {
+			t.Errorf("onFailure should only fire on the final attempt, retryCount: %d, triggered: %d", retryCount, count)
+		}
+	}
+	service.options.Standalone = false
+
+	// 每个分片触发一次，后续任务处理相同的分片
+	for page := int32(1); page <= 3; page++ {
+		service.invokeTask(testAddress, &task.TaskParams{TaskLogId: int64(11010 + page), Method: "app/retried.Handle", Page: page, Total: 3}, true)
+	}
+	pages := make(map[int32]bool)
+	for _, params := range triggered() {
+		if params.Total != 3 || pages[params.Page] {
+			t.Errorf("unexpected chained shard: %d/%d", params.Page, params.Total)
+		}
+		pages[params.Page] = true
+	}
+	if len(pages) != 3 {
+		t.Errorf("each shard should trigger once, pages: %v", pages)
+	}
+}
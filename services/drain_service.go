@@ -0,0 +1,117 @@
+package services
+
+// Created in 2026-10-18 19:40.
+// @author Horace
+
+import (
+	logger "github.com/cihub/seelog"
+	"github.com/horacedh/cronjob-executor/context"
+	"sync"
+	"time"
+)
+
+// 单例模式
+var (
+	drainService     DrainService
+	drainServiceOnce sync.Once
+)
+
+// DrainService 摘流服务，摘流后执行器从调度器注销并拒绝新的调度请求，队列中和正在执行的任务继续执行完成，进程保持存活，恢复后重新注册
+type DrainService interface {
+	// Drain 开始摘流，从调度器注销执行器，已经在摘流时返回false
+	Drain(address string) bool
+	// Resume 恢复接收调度请求，并要求立即重新注册执行器和全部任务，没有在摘流时返回false
+	Resume() bool
+	// IsDraining 是否正在摘流
+	IsDraining() bool
+	// IsDrained 是否摘流完成，队列中没有任务，没有正在执行的任务，任务结果已经全部发送
+	IsDrained() bool
+	// WaitDrained 等待摘流完成，超时返回false
+	WaitDrained(timeout time.Duration) bool
+	// OnResume 设置恢复后的回调，用于立即重新注册
+	OnResume(callback func())
+}
+
+// drainServiceImpl 实现类
+type drainServiceImpl struct {
+	mu sync.Mutex
+	// onResume 恢复后的回调
+	onResume func()
+}
+
+// Drain 开始摘流
+func (drainService *drainServiceImpl) Drain(address string) bool {
+	drainService.mu.Lock()
+	defer drainService.mu.Unlock()
+	if context.Draining.Load() {
+		return false
+	}
+
+	// 先拒绝新的调度请求，再从调度器注销，注销后心跳也会停止
+	context.Draining.Store(true)
+	GetRegisterService().Unregister(address)
+	logger.Infof("cron-job executor draining, address:%s", address)
+	return true
+}
+
+// Resume 恢复接收调度请求
+func (drainService *drainServiceImpl) Resume() bool {
+	drainService.mu.Lock()
+	defer drainService.mu.Unlock()
+	if !context.Draining.Load() {
+		return false
+	}
+
+	context.Draining.Store(false)
+	GetRegisterService().RequestRegister()
+	logger.Infof("cron-job executor resumed")
+	if drainService.onResume != nil {
+		go drainService.onResume()
+	}
+	return true
+}
+
+// IsDraining 是否正在摘流
+func (drainService *drainServiceImpl) IsDraining() bool {
+	return context.Draining.Load()
+}
+
+// IsDrained 是否摘流完成
+func (drainService *drainServiceImpl) IsDrained() bool {
+	if !context.Draining.Load() {
+		return false
+	}
+	if dispatcherService := GetDispatcherService(); dispatcherService != nil {
+		if dispatcherService.GetQueueSize() > 0 || dispatcherService.GetRunningCount() > 0 {
+			return false
+		}
+	}
+	return GetResultSendService().GetPendingCount() == 0
+}
+
+// WaitDrained 等待摘流完成
+func (drainService *drainServiceImpl) WaitDrained(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for !drainService.IsDrained() {
+		if !context.Draining.Load() || time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond * 200)
+	}
+	return true
+}
+
+// OnResume 设置恢复后的回调
+func (drainService *drainServiceImpl) OnResume(callback func()) {
+	drainService.mu.Lock()
+	defer drainService.mu.Unlock()
+	drainService.onResume = callback
+}
+
+// GetDrainService 获取实例对象
+func GetDrainService() DrainService {
+	drainServiceOnce.Do(func() {
+		drainService = &drainServiceImpl{}
+	})
+	return drainService
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/context"
+	"github.com/horacedh/cronjob-executor/httpclients"
+	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/utils"
+	"github.com/horacedh/cronjob-executor/webresult"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-18 20:10.
+// @author Horace
+
+// TestDrain 测试摘流时注销执行器，等待任务结果发送完成，恢复后要求重新注册
+func TestDrain(t *testing.T) {
+	var unregistered bool
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == apiExecutorUnregister {
+			unregistered = true
+		}
+		_, _ = writer.Write([]byte(utils.ToJsonString(webresult.SUCCESS)))
+	}))
+	defer server.Close()
+	httpclients.Init(httpclients.Options{Timeout: time.Second})
+	GetOpenApiService().SetHost(server.URL)
+
+	// 清空其他测试留下的任务结果
+	resultSendService := drainResults(t)
+	resultSendService.AddResult(&task.TaskResult{TaskLogId: 4001, State: task.EXECUTION_SUCCESS})
+
+	resumed := make(chan struct{})
+	drainService := &drainServiceImpl{}
+	drainService.OnResume(func() {
+		close(resumed)
+	})
+	if !drainService.Drain("127.0.0.1:8527") || drainService.Drain("127.0.0.1:8527") {
+		t.Fatalf("drain should only start once")
+	}
+	if !unregistered || !context.Draining.Load() || GetRegisterService().IsSuccess() {
+		t.Errorf("executor should be unregistered when draining")
+	}
+	if drainService.IsDrained() || drainService.WaitDrained(time.Millisecond*300) {
+		t.Errorf("drain should wait for pending results")
+	}
+
+	// 发送剩余的任务结果
+	if result := resultSendService.getTaskResult(); result == nil || !GetOpenApiService().SendTaskResult(result) {
+		t.Fatalf("send task result failed, result: %v", result)
+	}
+	resultSendService.sending.Add(-1)
+	if !drainService.WaitDrained(time.Second) {
+		t.Errorf("executor should be drained")
+	}
+
+	if !drainService.Resume() || drainService.Resume() {
+		t.Fatalf("resume should only succeed once")
+	}
+	select {
+	case <-resumed:
+	case <-time.After(time.Second):
+		t.Errorf("resume callback not called")
+	}
+	if context.Draining.Load() || !GetRegisterService().(*registerServiceImpl).registerRequested.Load() {
+		t.Errorf("executor should request reregister after resume")
+	}
+}
+
+// TestDrainDuringRegister 测试注册执行器的过程中开始摘流，注销等待注册完成后执行，摘流期间不会再注册执行器
+func TestDrainDuringRegister(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	registering := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == apiExecutorRegister {
+			registering <- struct{}{}
+			<-release
+		}
+		mu.Lock()
+		requests = append(requests, request.URL.Path)
+		mu.Unlock()
+		_, _ = writer.Write([]byte(utils.ToJsonString(webresult.SUCCESS)))
+	}))
+	defer server.Close()
+	httpclients.Init(httpclients.Options{Timeout: time.Second})
+	GetOpenApiService().SetHost(server.URL)
+	t.Cleanup(func() { context.Draining.Store(false) })
+
+	registerService := GetRegisterService()
+	registerService.RequestRegister()
+	registered := make(chan bool)
+	go func() {
+		registered <- registerService.SyncExecutor(bean.ExecutorOptions{AppName: "drain"}, "127.0.0.1:8527")
+	}()
+	<-registering
+
+	// 注册请求还没有返回时开始摘流
+	drained := make(chan bool)
+	go func() {
+		drained <- (&drainServiceImpl{}).Drain("127.0.0.1:8527")
+	}()
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	if !<-registered || !<-drained {
+		t.Fatalf("register and drain should both run")
+	}
+	mu.Lock()
+	order := append([]string{}, requests...)
+	mu.Unlock()
+	if len(order) != 2 || order[0] != apiExecutorRegister || order[1] != apiExecutorUnregister || registerService.IsSuccess() {
+		t.Errorf("executor should be unregistered after the in-flight register, requests: %v, success: %t", order, registerService.IsSuccess())
+	}
+
+	// 摘流期间要求重新注册也不会注册执行器
+	registerService.RequestRegister()
+	registerService.SyncExecutor(bean.ExecutorOptions{AppName: "drain"}, "127.0.0.1:8527")
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 2 || registerService.IsSuccess() {
+		t.Errorf("executor should not register while draining, requests: %v", requests)
+	}
+}
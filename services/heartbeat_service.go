@@ -4,9 +4,13 @@ package services
 // @author Horace
 
 import (
+	logger "github.com/cihub/seelog"
+	"github.com/horacedh/cronjob-executor/bean"
 	"github.com/horacedh/cronjob-executor/context"
 	"github.com/horacedh/cronjob-executor/utils"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,18 +22,39 @@ var (
 
 // HeartbeatService 接口
 type HeartbeatService interface {
-	// Start 启动心跳
-	Start(address string)
+	// Start 启动心跳，interval为心跳间隔时间，毫秒
+	Start(address string, interval int)
+	// GetStatus 获取心跳状态
+	GetStatus() bean.HeartbeatStatus
 }
 
 // heartbeatServiceImpl 实现类
 type heartbeatServiceImpl struct {
+	// success 最近一次心跳是否成功
+	success atomic.Bool
+	// lastHeartbeatTime 最近一次心跳的时间，毫秒
+	lastHeartbeatTime atomic.Int64
+	// lastSuccessTime 最近一次心跳成功的时间，毫秒
+	lastSuccessTime atomic.Int64
+}
+
+// GetStatus 获取心跳状态
+func (heartbeatService *heartbeatServiceImpl) GetStatus() bean.HeartbeatStatus {
+	return bean.HeartbeatStatus{
+		Success:           heartbeatService.success.Load(),
+		LastHeartbeatTime: heartbeatService.lastHeartbeatTime.Load(),
+		LastSuccessTime:   heartbeatService.lastSuccessTime.Load(),
+	}
 }
 
 // Start 启动心跳
-func (heartbeatService *heartbeatServiceImpl) Start(address string) {
-	// 每3秒一次注册
-	utils.GetScheduler().ScheduleAtFixedRate(time.Second*3, true, func() {
+func (heartbeatService *heartbeatServiceImpl) Start(address string, interval int) {
+	if interval <= 0 {
+		interval = bean.DefaultHeartbeatInterval
+	}
+
+	// 按照固定频率发送心跳，任务集合不变时，心跳是执行器唯一的存活信号
+	utils.GetScheduler().ScheduleAtFixedRate(time.Duration(interval)*time.Millisecond, true, func() {
 		// 如果已经停止，则不再发起心跳
 		if context.Shutdown.Load() {
 			//logger.Warnf("cronjob executor is shutdown, don't heartbeat, address:%s", address)
@@ -39,10 +64,46 @@ func (heartbeatService *heartbeatServiceImpl) Start(address string) {
 			time.Sleep(time.Second)
 			return
 		}
-		GetOpenApiService().Heartbeat(address)
+		success, reregister := GetOpenApiService().Heartbeat(heartbeatService.buildHeartbeatParams(address))
+		if reregister {
+			logger.Infof("cronjob scheduler requested reregister, address:%s", address)
+		}
+		// 心跳失败时调度器可能已经剔除了执行器，下一次检查注册状态时重新注册
+		if reregister || !success {
+			GetRegisterService().RequestRegister()
+		}
+		now := time.Now().UnixMilli()
+		heartbeatService.success.Store(success)
+		heartbeatService.lastHeartbeatTime.Store(now)
+		if success {
+			heartbeatService.lastSuccessTime.Store(now)
+		}
 	})
 }
 
+// buildHeartbeatParams 构建心跳参数，采集执行器的负载信息
+func (heartbeatService *heartbeatServiceImpl) buildHeartbeatParams(address string) bean.HeartbeatParams {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	params := bean.HeartbeatParams{
+		Address:     address,
+		Version:     context.Version,
+		StartTime:   context.StartTime.UnixMilli(),
+		Uptime:      time.Since(context.StartTime).Milliseconds(),
+		Goroutines:  runtime.NumGoroutine(),
+		NumCPU:      runtime.NumCPU(),
+		LoadAverage: utils.GetLoadAverage(),
+		MemoryAlloc: memStats.Alloc,
+		MemorySys:   memStats.Sys,
+	}
+	if dispatcherService := GetDispatcherService(); dispatcherService != nil {
+		params.RunningTasks = dispatcherService.GetRunningCount()
+		params.QueueSize = dispatcherService.GetQueueSize()
+	}
+	return params
+}
+
 // GetHeartbeatService 获取实例对象
 func GetHeartbeatService() HeartbeatService {
 	heartbeatServiceOnce.Do(func() {
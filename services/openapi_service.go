@@ -10,8 +10,12 @@ import (
 	"github.com/horacedh/cronjob-executor/bean"
 	"github.com/horacedh/cronjob-executor/context"
 	"github.com/horacedh/cronjob-executor/httpclients"
+	"github.com/horacedh/cronjob-executor/metrics"
 	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/tracing"
 	"github.com/horacedh/cronjob-executor/utils"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"sync"
 )
 
@@ -19,7 +23,13 @@ var apiExecutorRegister = "/openapi/executor/register"
 var apiExecutorUnregister = "/openapi/executor/unregister"
 var apiExecutorHeartbeat = "/openapi/executor/heartbeat"
 var apiTaskRegister = "/openapi/task/register"
+var apiTaskUnregister = "/openapi/task/unregister"
+var apiTaskList = "/openapi/task/list"
+var apiTaskTrigger = "/openapi/task/trigger"
 var apiTaskExecuteComplete = "/openapi/task/complete"
+var apiCheckpointGet = "/openapi/checkpoint/get"
+var apiCheckpointSave = "/openapi/checkpoint/save"
+var apiCheckpointDelete = "/openapi/checkpoint/delete"
 
 // 单例模式
 var (
@@ -35,12 +45,24 @@ type OpenApiService interface {
 	SetHost(address string)
 	// RegisterTask 注册任务
 	RegisterTask(params []bean.TaskRegisterParams) bool
-	// Heartbeat 心跳
-	Heartbeat(address string) bool
+	// UnregisterTask 注销任务，调度器不再分发已注销的任务
+	UnregisterTask(params bean.TaskUnregisterParams) bool
+	// ListTask 查询调度器中本应用和标签下已注册的任务
+	ListTask(params bean.TaskListParams) ([]bean.TaskRegisterParams, bool)
+	// TriggerTask 请求调度器立即触发任务，用于任务链的后续任务
+	TriggerTask(params bean.TaskTriggerParams) bool
+	// Heartbeat 心跳，携带执行器的负载信息，返回心跳是否成功，以及调度器是否要求重新注册
+	Heartbeat(params bean.HeartbeatParams) (bool, bool)
 	// UnregisterExecutor 注销执行器
 	UnregisterExecutor(address string) bool
 	// SendTaskResult 发送任务结果
 	SendTaskResult(result *task.TaskResult) bool
+	// GetCheckpoint 查询调度器中保存的检查点，返回游标和请求是否成功，不存在时游标为nil
+	GetCheckpoint(params bean.CheckpointParams) ([]byte, bool)
+	// SaveCheckpoint 保存检查点到调度器
+	SaveCheckpoint(params bean.CheckpointParams) bool
+	// DeleteCheckpoint 删除调度器中保存的检查点
+	DeleteCheckpoint(params bean.CheckpointParams) bool
 }
 
 // openApiServiceImpl 实现类
@@ -52,10 +74,18 @@ type openApiServiceImpl struct {
 func (openApiService *openApiServiceImpl) SendTaskResult(params *task.TaskResult) bool {
 	var commonHeaders = openApiService.getCommonHeaders()
 
+	ctx, span := tracing.Tracer().Start(params.Context(), tracing.SpanSendResult,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(tracing.AttrTaskLogId.Int64(params.TaskLogId), tracing.AttrTaskId.Int64(params.TaskId), tracing.AttrState.Int(int(params.State))))
+	defer span.End()
+
 	var url = openApiService.host + apiTaskExecuteComplete
 	jsonParams, _ := json.Marshal(params)
-	result := httpclients.GetHttpClient().PostRequest(url, commonHeaders, nil, bytes.NewReader(jsonParams))
+	result := httpclients.GetHttpClient().PostRequestWithContext(ctx, url, commonHeaders, nil, bytes.NewReader(jsonParams))
 	success := result.IsSuccess()
+	if !success {
+		span.SetStatus(codes.Error, "send task result failed")
+	}
 	if success {
 		logger.Debugf("cron job send task result success, serverAddress:%s, params:%v", openApiService.host, params)
 	} else {
@@ -83,19 +113,27 @@ func (openApiService *openApiServiceImpl) UnregisterExecutor(address string) boo
 }
 
 // Heartbeat 心跳
-func (openApiService *openApiServiceImpl) Heartbeat(address string) bool {
+func (openApiService *openApiServiceImpl) Heartbeat(params bean.HeartbeatParams) (bool, bool) {
 	var commonHeaders = openApiService.getCommonHeaders()
-	var params = make(map[string]string)
-	params["address"] = address
 
 	var url = openApiService.host + apiExecutorHeartbeat
 	jsonParams, _ := json.Marshal(params)
 	result := httpclients.GetHttpClient().PostRequest(url, commonHeaders, nil, bytes.NewReader(jsonParams))
 	success := result.IsSuccess()
+	metrics.Heartbeats.WithLabelValues(metrics.Result(success)).Inc()
 	if !success {
-		logger.Errorf("cron job heartbeat failed, serverAddress:%s, result:%v, params:%v", openApiService.host, result.MsgObject, params)
+		logger.Errorf("cron job heartbeat failed, serverAddress:%s, result:%v, params:%v", openApiService.host, result.MsgObject, utils.ToJsonString(params))
+		return false, false
 	}
-	return success
+
+	// 旧版本调度器的响应中没有data字段，不要求重新注册
+	var body struct {
+		Data *bean.HeartbeatResponse `json:"data"`
+	}
+	if err := json.Unmarshal(result.Body, &body); err != nil || body.Data == nil {
+		return true, false
+	}
+	return true, body.Data.Reregister
 }
 
 // RegisterTask 注册任务
@@ -106,6 +144,7 @@ func (openApiService *openApiServiceImpl) RegisterTask(params []bean.TaskRegiste
 	jsonParams, _ := json.Marshal(params)
 	result := httpclients.GetHttpClient().PostRequest(url, commonHeaders, nil, bytes.NewReader(jsonParams))
 	success := result.IsSuccess()
+	metrics.Registrations.WithLabelValues("task", metrics.Result(success)).Inc()
 	if success {
 		logger.Debugf("cron job task register success, serverAddress:%s, params:%v", openApiService.host, utils.ToJsonString(params))
 	} else {
@@ -114,6 +153,113 @@ func (openApiService *openApiServiceImpl) RegisterTask(params []bean.TaskRegiste
 	return success
 }
 
+// UnregisterTask 注销任务
+func (openApiService *openApiServiceImpl) UnregisterTask(params bean.TaskUnregisterParams) bool {
+	var commonHeaders = openApiService.getCommonHeaders()
+
+	var url = openApiService.host + apiTaskUnregister
+	jsonParams, _ := json.Marshal(params)
+	result := httpclients.GetHttpClient().PostRequest(url, commonHeaders, nil, bytes.NewReader(jsonParams))
+	success := result.IsSuccess()
+	metrics.Registrations.WithLabelValues("task_unregister", metrics.Result(success)).Inc()
+	if success {
+		logger.Infof("cron job task unregister success, serverAddress:%s, params:%v", openApiService.host, utils.ToJsonString(params))
+	} else {
+		logger.Errorf("cron job task unregister failed, serverAddress:%s, result:%v, params:%v", openApiService.host, result.MsgObject, utils.ToJsonString(params))
+	}
+	return success
+}
+
+// TriggerTask 请求调度器触发任务
+func (openApiService *openApiServiceImpl) TriggerTask(params bean.TaskTriggerParams) bool {
+	var commonHeaders = openApiService.getCommonHeaders()
+
+	var url = openApiService.host + apiTaskTrigger
+	jsonParams, _ := json.Marshal(params)
+	result := httpclients.GetHttpClient().PostRequest(url, commonHeaders, nil, bytes.NewReader(jsonParams))
+	success := result.IsSuccess()
+	if success {
+		logger.Infof("cron job task trigger success, serverAddress:%s, params:%v", openApiService.host, utils.ToJsonString(params))
+	} else {
+		logger.Errorf("cron job task trigger failed, serverAddress:%s, result:%v, params:%v", openApiService.host, result.MsgObject, utils.ToJsonString(params))
+	}
+	return success
+}
+
+// ListTask 查询调度器中已注册的任务
+func (openApiService *openApiServiceImpl) ListTask(params bean.TaskListParams) ([]bean.TaskRegisterParams, bool) {
+	var commonHeaders = openApiService.getCommonHeaders()
+
+	var url = openApiService.host + apiTaskList
+	jsonParams, _ := json.Marshal(params)
+	result := httpclients.GetHttpClient().PostRequest(url, commonHeaders, nil, bytes.NewReader(jsonParams))
+	if !result.IsSuccess() {
+		logger.Errorf("cron job task list failed, serverAddress:%s, result:%v, params:%v", openApiService.host, result.MsgObject, utils.ToJsonString(params))
+		return nil, false
+	}
+
+	// 响应体的data字段为任务数组
+	var body struct {
+		Data []bean.TaskRegisterParams `json:"data"`
+	}
+	if err := json.Unmarshal(result.Body, &body); err != nil {
+		logger.Errorf("cron job task list failed, unmarshal response failed, serverAddress:%s, err:%v", openApiService.host, err)
+		return nil, false
+	}
+	return body.Data, true
+}
+
+// GetCheckpoint 查询检查点
+func (openApiService *openApiServiceImpl) GetCheckpoint(params bean.CheckpointParams) ([]byte, bool) {
+	var commonHeaders = openApiService.getCommonHeaders()
+
+	var url = openApiService.host + apiCheckpointGet
+	jsonParams, _ := json.Marshal(params)
+	result := httpclients.GetHttpClient().PostRequest(url, commonHeaders, nil, bytes.NewReader(jsonParams))
+	if !result.IsSuccess() {
+		logger.Errorf("cron job checkpoint get failed, serverAddress:%s, result:%v, params:%v", openApiService.host, result.MsgObject, utils.ToJsonString(params))
+		return nil, false
+	}
+
+	// 响应体的data字段为检查点，不存在时为空
+	var body struct {
+		Data *bean.CheckpointParams `json:"data"`
+	}
+	if err := json.Unmarshal(result.Body, &body); err != nil {
+		logger.Errorf("cron job checkpoint get failed, unmarshal response failed, serverAddress:%s, err:%v", openApiService.host, err)
+		return nil, false
+	}
+	if body.Data == nil {
+		return nil, true
+	}
+	return body.Data.Cursor, true
+}
+
+// SaveCheckpoint 保存检查点
+func (openApiService *openApiServiceImpl) SaveCheckpoint(params bean.CheckpointParams) bool {
+	return openApiService.postCheckpoint(apiCheckpointSave, params)
+}
+
+// DeleteCheckpoint 删除检查点
+func (openApiService *openApiServiceImpl) DeleteCheckpoint(params bean.CheckpointParams) bool {
+	return openApiService.postCheckpoint(apiCheckpointDelete, params)
+}
+
+// postCheckpoint 发送保存或者删除检查点的请求
+func (openApiService *openApiServiceImpl) postCheckpoint(api string, params bean.CheckpointParams) bool {
+	var commonHeaders = openApiService.getCommonHeaders()
+
+	var url = openApiService.host + api
+	jsonParams, _ := json.Marshal(params)
+	result := httpclients.GetHttpClient().PostRequest(url, commonHeaders, nil, bytes.NewReader(jsonParams))
+	success := result.IsSuccess()
+	if !success {
+		params.Cursor = nil
+		logger.Errorf("cron job checkpoint request failed, serverAddress:%s, api:%s, result:%v, params:%v", openApiService.host, api, result.MsgObject, utils.ToJsonString(params))
+	}
+	return success
+}
+
 // SetHost 设置主机地址
 func (openApiService *openApiServiceImpl) SetHost(address string) {
 	openApiService.host = address
@@ -127,6 +273,7 @@ func (openApiService *openApiServiceImpl) RegisterExecutor(params bean.ExecutorR
 	jsonParams, _ := json.Marshal(params)
 	result := httpclients.GetHttpClient().PostRequest(url, commonHeaders, nil, bytes.NewReader(jsonParams))
 	success := result.IsSuccess()
+	metrics.Registrations.WithLabelValues("executor", metrics.Result(success)).Inc()
 	if success {
 		logger.Debugf("cron job executor register success, serverAddress:%s, params:%v", openApiService.host, utils.ToJsonString(params))
 	} else {
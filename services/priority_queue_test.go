@@ -9,13 +9,13 @@ import (
 
 func TestPriorityQueue(t *testing.T) {
 	queue := pq.NewWith(taskComparator) // empty
-	queue.Enqueue(task.TaskParams{ExecutionTime: 1})
-	queue.Enqueue(task.TaskParams{ExecutionTime: 2})
-	queue.Enqueue(task.TaskParams{ExecutionTime: 3})
-	queue.Enqueue(task.TaskParams{ExecutionTime: 4})
-	queue.Enqueue(task.TaskParams{ExecutionTime: 5})
-	queue.Enqueue(task.TaskParams{ExecutionTime: 6})
-	queue.Enqueue(task.TaskParams{ExecutionTime: 7})
+	queue.Enqueue(&task.TaskParams{ExecutionTime: 1})
+	queue.Enqueue(&task.TaskParams{ExecutionTime: 2})
+	queue.Enqueue(&task.TaskParams{ExecutionTime: 3})
+	queue.Enqueue(&task.TaskParams{ExecutionTime: 4})
+	queue.Enqueue(&task.TaskParams{ExecutionTime: 5})
+	queue.Enqueue(&task.TaskParams{ExecutionTime: 6})
+	queue.Enqueue(&task.TaskParams{ExecutionTime: 7})
 
 	fmt.Println(queue)
 	fmt.Println(queue.Size())
@@ -4,15 +4,25 @@ package services
 // @author Horace
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	logger "github.com/cihub/seelog"
 	"github.com/horacedh/cronjob-executor/bean"
 	"github.com/horacedh/cronjob-executor/context"
 	"os"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// registerTaskRetries 注册任务失败后的重试次数，仍然失败时由下一次检查注册状态时重新注册
+const registerTaskRetries = 3
+
+// registerTaskRetryInterval 注册任务失败后的重试间隔时间
+var registerTaskRetryInterval = time.Second
+
 // 单例模式
 var (
 	registerService     RegisterService
@@ -25,20 +35,68 @@ type RegisterService interface {
 	RegisterExecutor(option bean.ExecutorOptions, address string)
 	// RegisterTask 注册任务
 	RegisterTask(options bean.ExecutorOptions, option map[string]*bean.TaskOptions)
+	// SyncExecutor 执行器未注册成功，或者调度器要求重新注册时注册执行器，返回是否重新注册了执行器
+	SyncExecutor(options bean.ExecutorOptions, address string) bool
+	// SyncTask 任务集合的内容哈希与最近一次注册成功的不同时注册任务，force为true时总是注册
+	SyncTask(options bean.ExecutorOptions, taskOptions map[string]*bean.TaskOptions, force bool)
+	// RequestRegister 要求下一次检查注册状态时重新注册执行器和全部任务
+	RequestRegister()
+	// UnregisterTask 注销任务，methods为包路径+方法名
+	UnregisterTask(options bean.ExecutorOptions, methods []string) bool
+	// ReconcileTask 对比调度器中已注册的任务，按照孤儿任务策略处理代码中已经不存在的任务，返回孤儿任务方法
+	ReconcileTask(options bean.ExecutorOptions, taskOptions map[string]*bean.TaskOptions) []string
 	// IsSuccess 是否注册成功
 	IsSuccess() bool
 	// Unregister 注销执行器
 	Unregister(address string) bool
+	// GetStatus 获取注册状态
+	GetStatus() bean.RegisterStatus
 }
 
 // registerServiceImpl 实现类
 type registerServiceImpl struct {
+	// mu 注册和注销执行器互斥，摘流时的注销等待正在进行的注册完成，避免注销后又被注册
+	mu sync.Mutex
 	// success 标志位，用于判断是否已经成功注册
 	success atomic.Bool
+	// lastRegisterTime 最近一次注册执行器的时间，毫秒
+	lastRegisterTime atomic.Int64
+	// taskRegistered 标志位，用于判断任务是否已经注册成功
+	taskRegistered atomic.Bool
+	// lastTaskRegisterTime 最近一次注册任务的时间，毫秒
+	lastTaskRegisterTime atomic.Int64
+	// orphanTasks 启动时检查到的孤儿任务方法
+	orphanTasks atomic.Pointer[[]string]
+	// taskHash 最近一次注册成功的任务集合的内容哈希
+	taskHash atomic.Pointer[string]
+	// registerRequested 是否需要重新注册执行器和全部任务
+	registerRequested atomic.Bool
+}
+
+// GetStatus 获取注册状态
+func (registerService *registerServiceImpl) GetStatus() bean.RegisterStatus {
+	var orphanTasks []string
+	if pointer := registerService.orphanTasks.Load(); pointer != nil {
+		orphanTasks = *pointer
+	}
+	var taskHash string
+	if pointer := registerService.taskHash.Load(); pointer != nil {
+		taskHash = *pointer
+	}
+	return bean.RegisterStatus{
+		Success:              registerService.success.Load(),
+		LastRegisterTime:     registerService.lastRegisterTime.Load(),
+		TaskRegistered:       registerService.taskRegistered.Load(),
+		LastTaskRegisterTime: registerService.lastTaskRegisterTime.Load(),
+		TaskHash:             taskHash,
+		OrphanTasks:          orphanTasks,
+	}
 }
 
 // Unregister 注销执行器
 func (registerService *registerServiceImpl) Unregister(address string) bool {
+	registerService.mu.Lock()
+	defer registerService.mu.Unlock()
 	registerService.success.Store(false)
 	return GetOpenApiService().UnregisterExecutor(address)
 }
@@ -48,41 +106,138 @@ func (registerService *registerServiceImpl) IsSuccess() bool {
 	return registerService.success.Load()
 }
 
-// RegisterExecutor 注册执行器
+// RegisterExecutor 注册执行器，正在摘流时不注册，持有锁时检查，保证摘流开始后不会再注册
 func (registerService *registerServiceImpl) RegisterExecutor(options bean.ExecutorOptions, address string) {
+	registerService.mu.Lock()
+	defer registerService.mu.Unlock()
 	registerParams := registerService.buildExecutorRegisterParams(options, address)
-	success := GetOpenApiService().RegisterExecutor(registerParams)
-	registerService.success.Store(success)
+	for {
+		if context.Draining.Load() {
+			logger.Warnf("cronjob executor is draining, don't register executor, params:%v", registerParams)
+			return
+		}
+		success := GetOpenApiService().RegisterExecutor(registerParams)
+		registerService.success.Store(success)
+		registerService.lastRegisterTime.Store(time.Now().UnixMilli())
 
-	// 如果已经停止，则不再重试
-	if context.Shutdown.Load() {
-		logger.Warnf("cronjob executor is shutdown, don't retry register executor, params:%v", registerParams)
-		return
-	}
+		// 如果已经停止，则不再重试
+		if context.Shutdown.Load() {
+			logger.Warnf("cronjob executor is shutdown, don't retry register executor, params:%v", registerParams)
+			return
+		}
+		if success {
+			return
+		}
 
-	if !success {
-		// 如果注册不成功，则重试一次
+		// 如果注册不成功，则重试，摘流时停止重试，不会长时间阻塞注销
 		time.Sleep(time.Second)
-		registerService.RegisterExecutor(options, address)
 	}
 }
 
-// RegisterTask 注册任务
+// RegisterTask 注册任务，失败后最多重试registerTaskRetries次，不会一直阻塞调用方
 func (registerService *registerServiceImpl) RegisterTask(executorOptions bean.ExecutorOptions, taskOptions map[string]*bean.TaskOptions) {
 	var registerParams = registerService.buildTaskRegisterParams(executorOptions, taskOptions)
-	success := GetOpenApiService().RegisterTask(registerParams)
+	for attempt := 0; ; attempt++ {
+		success := GetOpenApiService().RegisterTask(registerParams)
+		registerService.taskRegistered.Store(success)
+		registerService.lastTaskRegisterTime.Store(time.Now().UnixMilli())
 
-	// 如果已经停止，则不再重试
-	if context.Shutdown.Load() {
-		logger.Warnf("cronjob executor is shutdown, don't retry register task, params:%v", registerParams)
+		// 如果已经停止，则不再重试
+		if context.Shutdown.Load() {
+			logger.Warnf("cronjob executor is shutdown, don't retry register task, params:%v", registerParams)
+			return
+		}
+		if success {
+			return
+		}
+		if attempt >= registerTaskRetries {
+			logger.Warnf("cronjob register task failed after %d retries, retry on the next register check, params:%v", registerTaskRetries, registerParams)
+			return
+		}
+
+		// 如果注册不成功，则重试
+		time.Sleep(registerTaskRetryInterval)
+	}
+}
+
+// SyncExecutor 按需注册执行器
+func (registerService *registerServiceImpl) SyncExecutor(options bean.ExecutorOptions, address string) bool {
+	requested := registerService.registerRequested.Swap(false)
+	if !requested && registerService.success.Load() {
+		return false
+	}
+	registerService.RegisterExecutor(options, address)
+	return true
+}
+
+// SyncTask 按需注册任务
+func (registerService *registerServiceImpl) SyncTask(options bean.ExecutorOptions, taskOptions map[string]*bean.TaskOptions, force bool) {
+	params := registerService.buildTaskRegisterParams(options, taskOptions)
+	hash := taskRegisterHash(params)
+	if current := registerService.taskHash.Load(); !force && current != nil && *current == hash {
 		return
 	}
 
+	registerService.RegisterTask(options, taskOptions)
+	if registerService.taskRegistered.Load() {
+		registerService.taskHash.Store(&hash)
+		logger.Infof("cronjob register tasks success, force:%t, tasks:%d, hash:%s", force, len(params), hash)
+	}
+}
+
+// RequestRegister 要求重新注册执行器和全部任务
+func (registerService *registerServiceImpl) RequestRegister() {
+	registerService.registerRequested.Store(true)
+}
+
+// UnregisterTask 注销任务
+func (registerService *registerServiceImpl) UnregisterTask(options bean.ExecutorOptions, methods []string) bool {
+	if len(methods) == 0 {
+		return true
+	}
+	return GetOpenApiService().UnregisterTask(bean.TaskUnregisterParams{
+		Tenant:  options.Tenant,
+		AppName: options.AppName,
+		Tag:     options.Tag,
+		Methods: methods,
+	})
+}
+
+// ReconcileTask 对比调度器中已注册的任务，处理孤儿任务
+func (registerService *registerServiceImpl) ReconcileTask(options bean.ExecutorOptions, taskOptions map[string]*bean.TaskOptions) []string {
+	if options.OrphanStrategy == bean.OrphanIgnore {
+		return nil
+	}
+
+	registeredTasks, success := GetOpenApiService().ListTask(bean.TaskListParams{
+		Tenant:  options.Tenant,
+		AppName: options.AppName,
+		Tag:     options.Tag,
+	})
 	if !success {
-		// 如果注册不成功，则重试一次
-		time.Sleep(time.Second)
-		registerService.RegisterTask(executorOptions, taskOptions)
+		logger.Warnf("cronjob reconcile task failed, can not list tasks from scheduler, appName:%s, tag:%s", options.AppName, options.Tag)
+		return nil
+	}
+
+	orphanTasks := make([]string, 0)
+	for _, registeredTask := range registeredTasks {
+		if taskOptions[registeredTask.Method] == nil {
+			orphanTasks = append(orphanTasks, registeredTask.Method)
+		}
 	}
+	sort.Strings(orphanTasks)
+	registerService.orphanTasks.Store(&orphanTasks)
+	if len(orphanTasks) == 0 {
+		return orphanTasks
+	}
+
+	if options.OrphanStrategy == bean.OrphanRetire {
+		logger.Warnf("cronjob reconcile task, retire orphan tasks, appName:%s, tag:%s, methods:%v", options.AppName, options.Tag, orphanTasks)
+		registerService.UnregisterTask(options, orphanTasks)
+	} else {
+		logger.Warnf("cronjob reconcile task, found orphan tasks that no longer exist in the executor, appName:%s, tag:%s, methods:%v", options.AppName, options.Tag, orphanTasks)
+	}
+	return orphanTasks
 }
 
 // buildExecutorRegisterParams 构建注册执行器的参数
@@ -124,6 +279,16 @@ func (registerService *registerServiceImpl) buildTaskRegisterParams(options bean
 	return params
 }
 
+// taskRegisterHash 计算任务注册参数的内容哈希，按照任务方法排序，与map的遍历顺序无关
+func taskRegisterHash(params []bean.TaskRegisterParams) string {
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Method < params[j].Method
+	})
+	data, _ := json.Marshal(params)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // GetRegisterService 获取实例对象
 func GetRegisterService() RegisterService {
 	registerServiceOnce.Do(func() {
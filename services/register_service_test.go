@@ -0,0 +1,176 @@
+package services
+
+import (
+	"encoding/json"
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/httpclients"
+	"github.com/horacedh/cronjob-executor/utils"
+	"github.com/horacedh/cronjob-executor/webresult"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-18 19:05.
+// @author Horace
+
+// TestReconcileTask 测试启动时检查并注销孤儿任务
+func TestReconcileTask(t *testing.T) {
+	var unregistered bean.TaskUnregisterParams
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case apiTaskList:
+			_, _ = writer.Write([]byte(utils.ToJsonString(webresult.Success([]bean.TaskRegisterParams{
+				{Method: "app/cronjob.DemoTask.Handle"},
+				{Method: "app/cronjob.OldTask.Handle"},
+				{Method: "app/cronjob.LegacyTask.Handle"},
+			}))))
+		case apiTaskUnregister:
+			_ = json.NewDecoder(request.Body).Decode(&unregistered)
+			_, _ = writer.Write([]byte(utils.ToJsonString(webresult.SUCCESS)))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	httpclients.Init(httpclients.Options{Timeout: time.Second})
+	GetOpenApiService().SetHost(server.URL)
+
+	taskOptions := map[string]*bean.TaskOptions{"app/cronjob.DemoTask.Handle": {Name: "demo"}}
+	options := bean.ExecutorOptions{Tenant: "horace", AppName: "app", Tag: "common"}
+	if orphanTasks := GetRegisterService().ReconcileTask(options, taskOptions); orphanTasks != nil {
+		t.Errorf("ignore strategy should not reconcile, orphanTasks: %v", orphanTasks)
+	}
+
+	options.OrphanStrategy = bean.OrphanFlag
+	expected := []string{"app/cronjob.LegacyTask.Handle", "app/cronjob.OldTask.Handle"}
+	if orphanTasks := GetRegisterService().ReconcileTask(options, taskOptions); !reflect.DeepEqual(orphanTasks, expected) || unregistered.Methods != nil {
+		t.Errorf("flag strategy should only report orphans, orphanTasks: %v, unregistered: %v", orphanTasks, unregistered)
+	}
+
+	options.OrphanStrategy = bean.OrphanRetire
+	GetRegisterService().ReconcileTask(options, taskOptions)
+	if !reflect.DeepEqual(unregistered.Methods, expected) || unregistered.AppName != "app" || unregistered.Tag != "common" {
+		t.Errorf("orphan tasks not retired, unregistered: %v", unregistered)
+	}
+	if status := GetRegisterService().GetStatus(); !reflect.DeepEqual(status.OrphanTasks, expected) {
+		t.Errorf("orphan tasks not in status, status: %v", status)
+	}
+}
+
+// TestSyncTask 测试任务集合不变时不重复注册，调度器要求时重新注册
+func TestSyncTask(t *testing.T) {
+	var taskRegisterCount, executorRegisterCount int
+	var reregister bool
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case apiTaskRegister:
+			taskRegisterCount++
+		case apiExecutorRegister:
+			executorRegisterCount++
+		case apiExecutorHeartbeat:
+			_, _ = writer.Write([]byte(utils.ToJsonString(webresult.Success(bean.HeartbeatResponse{Reregister: reregister}))))
+			return
+		}
+		_, _ = writer.Write([]byte(utils.ToJsonString(webresult.SUCCESS)))
+	}))
+	defer server.Close()
+	httpclients.Init(httpclients.Options{Timeout: time.Second})
+	GetOpenApiService().SetHost(server.URL)
+
+	registerService := &registerServiceImpl{}
+	options := bean.ExecutorOptions{Tenant: "horace", AppName: "app", Tag: "common"}
+	taskOptions := map[string]*bean.TaskOptions{
+		"app/cronjob.DemoTask.Handle":  {Name: "demo", Cron: "0 0 * * * ?"},
+		"app/cronjob.DemoTask1.Handle": {Name: "demo1", Cron: "0 0 * * * ?"},
+	}
+
+	if !registerService.SyncExecutor(options, "127.0.0.1:8527") || registerService.SyncExecutor(options, "127.0.0.1:8527") {
+		t.Errorf("executor should only be registered when not registered")
+	}
+	registerService.SyncTask(options, taskOptions, false)
+	registerService.SyncTask(options, map[string]*bean.TaskOptions{
+		"app/cronjob.DemoTask1.Handle": {Name: "demo1", Cron: "0 0 * * * ?"},
+		"app/cronjob.DemoTask.Handle":  {Name: "demo", Cron: "0 0 * * * ?"},
+	}, false)
+	if taskRegisterCount != 1 {
+		t.Errorf("unchanged tasks should not be registered again, count: %d", taskRegisterCount)
+	}
+	taskOptions["app/cronjob.DemoTask.Handle"] = &bean.TaskOptions{Name: "demo", Cron: "0 30 * * * ?"}
+	registerService.SyncTask(options, taskOptions, false)
+	if taskRegisterCount != 2 {
+		t.Errorf("changed tasks should be registered, count: %d", taskRegisterCount)
+	}
+
+	// 调度器在心跳响应中要求重新注册
+	reregister = true
+	if success, requested := GetOpenApiService().Heartbeat(bean.HeartbeatParams{Address: "127.0.0.1:8527"}); !success || !requested {
+		t.Fatalf("heartbeat should request reregister, success: %t, requested: %t", success, requested)
+	}
+	registerService.RequestRegister()
+	if force := registerService.SyncExecutor(options, "127.0.0.1:8527"); force {
+		registerService.SyncTask(options, taskOptions, force)
+	}
+	if executorRegisterCount != 2 || taskRegisterCount != 3 {
+		t.Errorf("requested reregister not performed, executor: %d, task: %d", executorRegisterCount, taskRegisterCount)
+	}
+}
+
+// TestRegisterTaskRetry 测试注册任务失败后有限次重试，不会一直阻塞
+func TestRegisterTaskRetry(t *testing.T) {
+	var taskRegisterCount int
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == apiTaskRegister {
+			taskRegisterCount++
+		}
+		_, _ = writer.Write([]byte(utils.ToJsonString(webresult.ERROR)))
+	}))
+	defer server.Close()
+	httpclients.Init(httpclients.Options{Timeout: time.Second})
+	GetOpenApiService().SetHost(server.URL)
+	interval := registerTaskRetryInterval
+	registerTaskRetryInterval = time.Millisecond * 10
+	defer func() { registerTaskRetryInterval = interval }()
+
+	registerService := &registerServiceImpl{}
+	registerService.RegisterTask(bean.ExecutorOptions{Tenant: "horace", AppName: "app", Tag: "common"}, map[string]*bean.TaskOptions{
+		"app/cronjob.DemoTask.Handle": {Name: "demo", Cron: "0 0 * * * ?"},
+	})
+	if taskRegisterCount != registerTaskRetries+1 || registerService.taskRegistered.Load() {
+		t.Errorf("register task should stop after %d retries, count: %d", registerTaskRetries, taskRegisterCount)
+	}
+}
+
+// TestHeartbeatPayload 测试心跳携带负载信息，并且保留旧版本调度器使用的address字段
+func TestHeartbeatPayload(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_ = json.NewDecoder(request.Body).Decode(&body)
+		_, _ = writer.Write([]byte(utils.ToJsonString(webresult.SUCCESS)))
+	}))
+	defer server.Close()
+	httpclients.Init(httpclients.Options{Timeout: time.Second})
+	GetOpenApiService().SetHost(server.URL)
+
+	params := GetHeartbeatService().(*heartbeatServiceImpl).buildHeartbeatParams("127.0.0.1:8527")
+	if params.Goroutines <= 0 || params.NumCPU <= 0 || params.MemorySys == 0 || params.Uptime < 0 {
+		t.Errorf("unexpected heartbeat params: %+v", params)
+	}
+	if success, reregister := GetOpenApiService().Heartbeat(params); !success || reregister {
+		t.Fatalf("heartbeat failed, success: %t, reregister: %t", success, reregister)
+	}
+	for _, field := range []string{"address", "version", "uptime", "runningTasks", "queueSize", "goroutines", "loadAverage", "memoryAlloc"} {
+		if _, ok := body[field]; !ok {
+			t.Errorf("heartbeat body missing field %s, body: %v", field, body)
+		}
+	}
+	if body["address"] != "127.0.0.1:8527" {
+		t.Errorf("heartbeat address not sent, body: %v", body)
+	}
+	// 任务在独立的goroutine中执行，没有工作池容量，不上报空闲数量
+	if _, ok := body["workerFreeSlots"]; ok {
+		t.Errorf("heartbeat body should not contain workerFreeSlots, body: %v", body)
+	}
+}
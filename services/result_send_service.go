@@ -8,8 +8,11 @@ import (
 	"github.com/emirpasic/gods/queues/priorityqueue"
 	"github.com/emirpasic/gods/utils"
 	"github.com/horacedh/cronjob-executor/context"
+	"github.com/horacedh/cronjob-executor/metrics"
 	"github.com/horacedh/cronjob-executor/task"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,6 +28,12 @@ type ResultSendService interface {
 	Start()
 	// AddResult 添加任务结果
 	AddResult(result *task.TaskResult) int
+	// GetPendingResults 获取等待发送的任务结果，按照实际执行时间升序排序
+	GetPendingResults() []*task.TaskResult
+	// GetPendingCount 获取等待发送和正在发送的任务结果数量
+	GetPendingCount() int
+	// HasPendingResult 是否有等待发送的任务结果
+	HasPendingResult(taskLogId int64) bool
 }
 
 // resultSendServiceImpl 实现类
@@ -32,6 +41,8 @@ type resultSendServiceImpl struct {
 	mu sync.Mutex
 	// resultQueue 任务结果队列，按照执行时间升序排序
 	resultQueue *priorityqueue.Queue
+	// sending 正在发送的任务结果数量
+	sending atomic.Int64
 }
 
 // AddResult 添加任务结果
@@ -40,7 +51,44 @@ func (resultSendService *resultSendServiceImpl) AddResult(result *task.TaskResul
 	defer resultSendService.mu.Unlock()
 
 	resultSendService.resultQueue.Enqueue(result)
-	return resultSendService.resultQueue.Size()
+	size := resultSendService.resultQueue.Size()
+	metrics.ResultQueueSize.Set(float64(size))
+	return size
+}
+
+// GetPendingResults 获取等待发送的任务结果
+func (resultSendService *resultSendServiceImpl) GetPendingResults() []*task.TaskResult {
+	resultSendService.mu.Lock()
+	values := resultSendService.resultQueue.Values()
+	resultSendService.mu.Unlock()
+
+	results := make([]*task.TaskResult, 0, len(values))
+	for _, value := range values {
+		results = append(results, value.(*task.TaskResult))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].RealExecutionTime < results[j].RealExecutionTime
+	})
+	return results
+}
+
+// GetPendingCount 获取等待发送和正在发送的任务结果数量
+func (resultSendService *resultSendServiceImpl) GetPendingCount() int {
+	resultSendService.mu.Lock()
+	defer resultSendService.mu.Unlock()
+	return resultSendService.resultQueue.Size() + int(resultSendService.sending.Load())
+}
+
+// HasPendingResult 是否有等待发送的任务结果
+func (resultSendService *resultSendServiceImpl) HasPendingResult(taskLogId int64) bool {
+	resultSendService.mu.Lock()
+	defer resultSendService.mu.Unlock()
+	for _, value := range resultSendService.resultQueue.Values() {
+		if value.(*task.TaskResult).TaskLogId == taskLogId {
+			return true
+		}
+	}
+	return false
 }
 
 // Start 开始发送任务结果
@@ -60,8 +108,13 @@ func (resultSendService *resultSendServiceImpl) Start() {
 		// 发送http请求
 		success := GetOpenApiService().SendTaskResult(taskResult)
 		if !success {
-			resultSendService.AddResult(taskResult)
+			metrics.ResultSendFailures.Inc()
+			// 重试中的状态发送失败时不再重新发送，避免在最终结果之后发送
+			if taskResult.State != task.EXECUTION_FAILED_RETRYING {
+				resultSendService.AddResult(taskResult)
+			}
 		}
+		resultSendService.sending.Add(-1)
 	}
 
 	logger.Infof("result send service is stopped.")
@@ -73,9 +126,11 @@ func (resultSendService *resultSendServiceImpl) getTaskResult() *task.TaskResult
 	resultSendService.mu.Lock()
 	defer resultSendService.mu.Unlock()
 	taskResult, _ := resultSendService.resultQueue.Dequeue()
+	metrics.ResultQueueSize.Set(float64(resultSendService.resultQueue.Size()))
 	if taskResult == nil {
 		return nil
 	}
+	resultSendService.sending.Add(1)
 	return taskResult.(*task.TaskResult)
 }
 
@@ -90,9 +145,17 @@ func GetResultSendService() ResultSendService {
 	return resultSendService
 }
 
-// taskResultComparator 比较器
+// taskResultComparator 比较器，执行时间相同时按照耗时升序，同一个任务本地重试中的状态先于最终结果发送
 func taskResultComparator(a, b interface{}) int {
-	priorityA := a.(*task.TaskResult).RealExecutionTime
-	priorityB := b.(*task.TaskResult).RealExecutionTime
-	return utils.Int64Comparator(priorityA, priorityB) // "-" descending order
+	resultA, resultB := a.(*task.TaskResult), b.(*task.TaskResult)
+	if order := utils.Int64Comparator(resultA.RealExecutionTime, resultB.RealExecutionTime); order != 0 {
+		return order // "-" descending order
+	}
+	if retryingA, retryingB := resultA.State == task.EXECUTION_FAILED_RETRYING, resultB.State == task.EXECUTION_FAILED_RETRYING; retryingA != retryingB {
+		if retryingA {
+			return -1
+		}
+		return 1
+	}
+	return utils.IntComparator(resultA.ElapsedTime, resultB.ElapsedTime)
 }
@@ -0,0 +1,325 @@
+package services
+
+// Created in 2026-10-18 15:02.
+// @author Horace
+
+import (
+	logger "github.com/cihub/seelog"
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/context"
+	"github.com/horacedh/cronjob-executor/cron"
+	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/utils"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 单例模式
+var (
+	standaloneService     StandaloneService
+	standaloneServiceOnce sync.Once
+)
+
+// StandaloneService 单机模式服务，不依赖调度器，由执行器自己解析CRON表达式生成任务
+type StandaloneService interface {
+	// Start 开始生成任务，运行时任务注册表的修改会在下一轮生成任务时生效
+	Start(options bean.ExecutorOptions, registry TaskRegistry)
+	// onResult 处理任务结果，按照失败策略在本地重试
+	onResult(result *task.TaskResult)
+}
+
+// standaloneTask 单机模式下的任务
+type standaloneTask struct {
+	// taskId 任务ID，本地生成
+	taskId int64
+	// method 任务方法，包路径+方法名
+	method string
+	// options 任务配置
+	options *bean.TaskOptions
+	// schedule 解析后的CRON表达式
+	schedule *cron.Schedule
+	// nextFireTime 下一次触发时间
+	nextFireTime time.Time
+}
+
+// standaloneRun 单机模式下的一次任务执行，用于失败重试
+type standaloneRun struct {
+	// params 任务参数
+	params *task.TaskParams
+	// options 任务配置
+	options *bean.TaskOptions
+	// retryCount 已重试次数
+	retryCount int
+}
+
+// standaloneServiceImpl 实现类
+type standaloneServiceImpl struct {
+	// tag 执行器标签
+	tag string
+	// registry 任务注册表
+	registry TaskRegistry
+	// version 已经同步的任务注册表版本号
+	version int64
+	// tasks 单机模式下的任务集合
+	tasks []*standaloneTask
+	// taskIds 任务ID集合，key为任务方法，任务被移除后重新添加时ID保持不变
+	taskIds map[string]int64
+	// runs 正在执行的任务，key为任务日志ID
+	runs sync.Map
+	// taskLogId 本地生成的任务日志ID
+	taskLogId atomic.Int64
+	// now 获取当前时间，测试时替换为固定的时钟
+	now func() time.Time
+	// addTask 将生成的任务加入调度队列
+	addTask func(params *task.TaskParams)
+}
+
+// Start 开始生成任务
+func (standaloneService *standaloneServiceImpl) Start(options bean.ExecutorOptions, registry TaskRegistry) {
+	standaloneService.tag = options.Tag
+	standaloneService.registry = registry
+	standaloneService.taskIds = make(map[string]int64)
+	standaloneService.taskLogId.Store(standaloneService.now().UnixMilli())
+	standaloneService.sync()
+
+	logger.Infof("start cron-job standalone mode, tasks:%d", len(standaloneService.tasks))
+	go standaloneService.run()
+}
+
+// sync 同步任务注册表，新增或者修改了CRON表达式的任务重新计算下一次触发时间，已移除的任务不再生成
+func (standaloneService *standaloneServiceImpl) sync() {
+	version := standaloneService.registry.Version()
+	taskOptions := standaloneService.registry.Options()
+
+	existing := make(map[string]*standaloneTask, len(standaloneService.tasks))
+	for _, standaloneTask := range standaloneService.tasks {
+		existing[standaloneTask.method] = standaloneTask
+	}
+
+	// 按照任务方法排序，保证任务ID稳定
+	methods := make([]string, 0, len(taskOptions))
+	for method := range taskOptions {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	now := standaloneService.now()
+	tasks := make([]*standaloneTask, 0, len(methods))
+	for _, method := range methods {
+		options := taskOptions[method]
+		if current := existing[method]; current != nil && current.options.Cron == options.Cron {
+			current.options = options
+			tasks = append(tasks, current)
+			continue
+		}
+
+		schedule, err := cron.Parse(options.Cron)
+		if err != nil {
+			logger.Errorf("standalone mode, parse cron failed, task will not be scheduled, method:%s, err:%v", method, err)
+			continue
+		}
+		taskId, ok := standaloneService.taskIds[method]
+		if !ok {
+			taskId = int64(len(standaloneService.taskIds) + 1)
+			standaloneService.taskIds[method] = taskId
+		}
+		tasks = append(tasks, &standaloneTask{
+			taskId:       taskId,
+			method:       method,
+			options:      options,
+			schedule:     schedule,
+			nextFireTime: schedule.Next(now),
+		})
+	}
+	standaloneService.tasks = tasks
+	standaloneService.version = version
+}
+
+// run 循环生成到期的任务，提前1秒加入调度队列，由调度服务按照执行时间精确执行
+func (standaloneService *standaloneServiceImpl) run() {
+	for !context.Shutdown.Load() {
+		if standaloneService.registry.Version() != standaloneService.version {
+			standaloneService.sync()
+			logger.Infof("standalone mode, tasks changed, tasks:%d", len(standaloneService.tasks))
+		}
+
+		// 摘流时不再生成新的任务，恢复后错过的触发时间按照过期策略处理
+		if context.Draining.Load() {
+			time.Sleep(time.Millisecond * 200)
+			continue
+		}
+
+		standaloneService.generate(standaloneService.now())
+		time.Sleep(time.Millisecond * 200)
+	}
+	logger.Infof("standalone mode stopped.")
+}
+
+// generate 生成到now时已经到期或者1秒内到期的任务
+func (standaloneService *standaloneServiceImpl) generate(now time.Time) {
+	for _, standaloneTask := range standaloneService.tasks {
+		if standaloneTask.nextFireTime.IsZero() || standaloneTask.nextFireTime.Sub(now) > time.Second {
+			continue
+		}
+
+		fireTime := standaloneTask.nextFireTime
+		var exeType = task.ExeTypeNormal
+
+		// 超过过期时间还没有生成任务，按照过期策略处理，错过的触发时间只处理一次
+		if now.Sub(fireTime) > time.Duration(standaloneTask.options.ExpireTime)*time.Millisecond {
+			standaloneTask.nextFireTime = standaloneTask.schedule.Next(now)
+			if standaloneTask.options.ExpiredStrategy == bean.ExpiredDiscard {
+				logger.Warnf("standalone mode, task expired and discarded, method:%s, executionTime:%s", standaloneTask.method, utils.FormatTime(fireTime.UnixMilli()))
+				continue
+			}
+			exeType = task.ExeTypeExpired
+		} else {
+			standaloneTask.nextFireTime = standaloneTask.schedule.Next(fireTime)
+		}
+
+		params := &task.TaskParams{
+			Page:          1,
+			Total:         1,
+			TaskLogId:     standaloneService.taskLogId.Add(1),
+			TaskId:        standaloneTask.taskId,
+			Method:        standaloneTask.method,
+			ExeType:       exeType,
+			Cron:          standaloneTask.options.Cron,
+			Tag:           standaloneService.tag,
+			ExecutionTime: fireTime.UnixMilli(),
+			ScheduleTime:  fireTime.UnixMilli(),
+		}
+		standaloneService.runs.Store(params.TaskLogId, &standaloneRun{params: params, options: standaloneTask.options})
+		standaloneService.addTask(params)
+	}
+}
+
+// onResult 处理任务结果，按照失败策略在本地重试
+func (standaloneService *standaloneServiceImpl) onResult(result *task.TaskResult) {
+	value, ok := standaloneService.runs.Load(result.TaskLogId)
+	if !ok {
+		logger.Infof("standalone mode, task result:%s", utils.ToJsonString(result))
+		return
+	}
+	run := value.(*standaloneRun)
+
+	// 本地重试中的状态，等待本地重试的最终结果
+	if result.State == task.EXECUTION_FAILED_RETRYING {
+		logger.Warnf("standalone mode, task failed, retrying locally, method:%s, result:%s", run.params.Method, utils.ToJsonString(result))
+		return
+	}
+
+	if result.State == task.EXECUTION_FAILED {
+		// 任务已经被移除时不再重试
+		_, current := standaloneService.registry.Get(run.params.Method)
+		if run.options.FailureStrategy == bean.FailureRetry && run.retryCount < run.options.MaxRetryCount && current != nil && !context.Shutdown.Load() {
+			run.retryCount++
+			retryParams := *run.params
+			retryParams.ExecutionTime = standaloneService.now().UnixMilli() + int64(run.options.FailureRetryInterval)
+			retryParams.RetryCount = run.retryCount
+			retryParams.SetContext(nil)
+			run.params = &retryParams
+			logger.Warnf("standalone mode, task failed, retrying, state:%d, retryCount:%d, maxRetryCount:%d, result:%s",
+				task.EXECUTION_FAILED_RETRYING, run.retryCount, run.options.MaxRetryCount, utils.ToJsonString(result))
+			standaloneService.addTask(&retryParams)
+			return
+		}
+		if run.options.FailureStrategy == bean.FailureDiscard {
+			result.State = task.EXECUTION_FAILED_DISCARD
+		}
+	}
+
+	standaloneService.runs.Delete(result.TaskLogId)
+	if result.State == task.EXECUTION_SUCCESS {
+		logger.Debugf("standalone mode, task success, method:%s, result:%s", run.params.Method, utils.ToJsonString(result))
+	} else {
+		logger.Errorf("standalone mode, task failed, method:%s, retryCount:%d, result:%s", run.params.Method, run.retryCount, utils.ToJsonString(result))
+	}
+}
+
+// GetStandaloneService 获取实例对象
+func GetStandaloneService() StandaloneService {
+	standaloneServiceOnce.Do(func() {
+		standaloneService = &standaloneServiceImpl{
+			now: time.Now,
+			addTask: func(params *task.TaskParams) {
+				GetDispatcherService().AddTask(params)
+			},
+		}
+	})
+	return standaloneService
+}
+
+// standaloneOpenApiServiceImpl 单机模式下的OpenApi实现，不请求调度器，任务结果交给单机模式服务处理
+type standaloneOpenApiServiceImpl struct {
+}
+
+// RegisterExecutor 注册执行器，单机模式下直接返回成功
+func (openApiService *standaloneOpenApiServiceImpl) RegisterExecutor(params bean.ExecutorRegisterParams) bool {
+	return true
+}
+
+// SetHost 设置主机地址，单机模式下忽略
+func (openApiService *standaloneOpenApiServiceImpl) SetHost(address string) {
+}
+
+// RegisterTask 注册任务，单机模式下直接返回成功
+func (openApiService *standaloneOpenApiServiceImpl) RegisterTask(params []bean.TaskRegisterParams) bool {
+	return true
+}
+
+// UnregisterTask 注销任务，单机模式下直接返回成功
+func (openApiService *standaloneOpenApiServiceImpl) UnregisterTask(params bean.TaskUnregisterParams) bool {
+	return true
+}
+
+// ListTask 查询已注册的任务，单机模式下没有调度器，返回空
+func (openApiService *standaloneOpenApiServiceImpl) ListTask(params bean.TaskListParams) ([]bean.TaskRegisterParams, bool) {
+	return nil, true
+}
+
+// Heartbeat 心跳，单机模式下直接返回成功
+func (openApiService *standaloneOpenApiServiceImpl) Heartbeat(params bean.HeartbeatParams) (bool, bool) {
+	return true, false
+}
+
+// UnregisterExecutor 注销执行器，单机模式下直接返回成功
+func (openApiService *standaloneOpenApiServiceImpl) UnregisterExecutor(address string) bool {
+	return true
+}
+
+// SendTaskResult 发送任务结果，单机模式下交给单机模式服务处理
+func (openApiService *standaloneOpenApiServiceImpl) SendTaskResult(result *task.TaskResult) bool {
+	GetStandaloneService().onResult(result)
+	return true
+}
+
+// TriggerTask 请求调度器触发任务，单机模式下没有调度器，返回失败
+func (openApiService *standaloneOpenApiServiceImpl) TriggerTask(params bean.TaskTriggerParams) bool {
+	logger.Warnf("standalone mode, scheduler trigger is not supported, use local chain mode instead, params:%s", utils.ToJsonString(params))
+	return false
+}
+
+// GetCheckpoint 查询检查点，单机模式下没有调度器，返回失败
+func (openApiService *standaloneOpenApiServiceImpl) GetCheckpoint(params bean.CheckpointParams) ([]byte, bool) {
+	return nil, false
+}
+
+// SaveCheckpoint 保存检查点，单机模式下没有调度器，返回失败
+func (openApiService *standaloneOpenApiServiceImpl) SaveCheckpoint(params bean.CheckpointParams) bool {
+	return false
+}
+
+// DeleteCheckpoint 删除检查点，单机模式下没有调度器，返回失败
+func (openApiService *standaloneOpenApiServiceImpl) DeleteCheckpoint(params bean.CheckpointParams) bool {
+	return false
+}
+
+// UseStandaloneOpenApiService 使用单机模式的OpenApi实现，需要在调用GetOpenApiService之前调用
+func UseStandaloneOpenApiService() {
+	openApiServiceOnce.Do(func() {
+		openApiService = &standaloneOpenApiServiceImpl{}
+	})
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/task"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-19 02:10.
+// @author Horace
+
+// testClock 测试用的固定时钟
+type testClock struct {
+	now time.Time
+}
+
+// newTestStandalone 创建使用固定时钟的单机模式服务，生成的任务不加入调度队列，按照顺序记录在返回的切片中
+func newTestStandalone(registry TaskRegistry, clock *testClock) (*standaloneServiceImpl, *[]*task.TaskParams) {
+	var added []*task.TaskParams
+	service := &standaloneServiceImpl{
+		registry: registry,
+		taskIds:  make(map[string]int64),
+		now:      func() time.Time { return clock.now },
+		addTask: func(params *task.TaskParams) {
+			added = append(added, params)
+		},
+	}
+	service.sync()
+	return service, &added
+}
+
+// putStandaloneTask 添加单机模式的任务
+func putStandaloneTask(registry TaskRegistry, method string, options *bean.TaskOptions) {
+	handler := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult { return task.Success() })
+	registry.Put(method, &handler, options)
+}
+
+// TestStandaloneGenerate 测试按照CRON表达式提前1秒生成任务，每个触发时间只生成一次，修改CRON表达式后重新计算触发时间
+func TestStandaloneGenerate(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 500, time.Local)
+	clock := &testClock{now: start}
+	registry := NewTaskRegistry()
+	putStandaloneTask(registry, "app/report.Handle", &bean.TaskOptions{Name: "report", Cron: "0/10 * * * * ?", ExpireTime: 5000})
+	putStandaloneTask(registry, "app/invalid.Handle", &bean.TaskOptions{Name: "invalid", Cron: "0 0 0 * * *"})
+	service, added := newTestStandalone(registry, clock)
+	if len(service.tasks) != 1 {
+		t.Fatalf("task with invalid cron should not be scheduled, tasks: %d", len(service.tasks))
+	}
+
+	service.generate(start.Add(time.Second * 8))
+	if len(*added) != 0 {
+		t.Fatalf("task should not be generated more than 1 second ahead, added: %d", len(*added))
+	}
+	for i := 0; i < 3; i++ {
+		service.generate(start.Add(time.Millisecond * 9200))
+	}
+	fireTime := time.Date(2026, 10, 18, 10, 0, 10, 0, time.Local)
+	if len(*added) != 1 {
+		t.Fatalf("fire time should be generated once, added: %d", len(*added))
+	}
+	params := (*added)[0]
+	if params.ExeType != task.ExeTypeNormal || params.ExecutionTime != fireTime.UnixMilli() || params.ScheduleTime != fireTime.UnixMilli() || params.TaskId != 1 {
+		t.Errorf("unexpected task params: %+v", params)
+	}
+
+	service.generate(start.Add(time.Millisecond * 19200))
+	if len(*added) != 2 || (*added)[1].ExecutionTime != fireTime.Add(time.Second*10).UnixMilli() || (*added)[1].TaskLogId != params.TaskLogId+1 {
+		t.Fatalf("next fire time should be generated, added: %d", len(*added))
+	}
+
+	// 修改CRON表达式后从当前时间重新计算下一次触发时间，任务ID保持不变
+	clock.now = start.Add(time.Second * 20)
+	putStandaloneTask(registry, "app/report.Handle", &bean.TaskOptions{Name: "report", Cron: "0 * * * * ?", ExpireTime: 5000})
+	service.sync()
+	if next := service.tasks[0].nextFireTime; !next.Equal(time.Date(2026, 10, 18, 10, 1, 0, 0, time.Local)) || service.tasks[0].taskId != 1 {
+		t.Errorf("changed cron should be rescheduled, next fire time: %v, task id: %d", next, service.tasks[0].taskId)
+	}
+}
+
+// TestStandaloneExpired 测试错过的触发时间超过过期时间后按照过期策略处理，多个错过的触发时间只处理一次
+func TestStandaloneExpired(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 500, time.Local)
+	clock := &testClock{now: start}
+	registry := NewTaskRegistry()
+	putStandaloneTask(registry, "app/execute.Handle", &bean.TaskOptions{Name: "execute", Cron: "0/10 * * * * ?", ExpireTime: 5000, ExpiredStrategy: bean.ExpiredExecute})
+	putStandaloneTask(registry, "app/discard.Handle", &bean.TaskOptions{Name: "discard", Cron: "0/10 * * * * ?", ExpireTime: 5000, ExpiredStrategy: bean.ExpiredDiscard})
+	service, added := newTestStandalone(registry, clock)
+
+	// 在过期时间内的错过的触发时间正常执行
+	service.generate(start.Add(time.Second * 13))
+	if len(*added) != 2 || (*added)[0].ExeType != task.ExeTypeNormal || (*added)[1].ExeType != task.ExeTypeNormal {
+		t.Fatalf("missed fire time within expire time should be executed normally, added: %d", len(*added))
+	}
+
+	// 错过了10:00:20到10:01:00的5个触发时间
+	now := start.Add(time.Minute)
+	service.generate(now)
+	service.generate(now)
+	if len(*added) != 3 {
+		t.Fatalf("misfire should be handled once and discarded by the discard strategy, added: %d", len(*added))
+	}
+	expired := (*added)[2]
+	if expired.Method != "app/execute.Handle" || expired.ExeType != task.ExeTypeExpired || expired.ExecutionTime != time.Date(2026, 10, 18, 10, 0, 20, 0, time.Local).UnixMilli() {
+		t.Errorf("expired task should be executed once with the first missed fire time, params: %+v", expired)
+	}
+	for _, standaloneTask := range service.tasks {
+		if !standaloneTask.nextFireTime.Equal(time.Date(2026, 10, 18, 10, 1, 10, 0, time.Local)) {
+			t.Errorf("next fire time should be computed from now, method: %s, next: %v", standaloneTask.method, standaloneTask.nextFireTime)
+		}
+	}
+}
+
+// TestStandaloneFailure 测试按照失败策略在本地重试，最多重试MaxRetryCount次，丢弃策略和已移除的任务不重试
+func TestStandaloneFailure(t *testing.T) {
+	start := time.Date(2026, 10, 18, 10, 0, 0, 500, time.Local)
+	clock := &testClock{now: start}
+	registry := NewTaskRegistry()
+	putStandaloneTask(registry, "app/retry.Handle", &bean.TaskOptions{Name: "retry", Cron: "0/10 * * * * ?", ExpireTime: 5000, FailureStrategy: bean.FailureRetry, MaxRetryCount: 2, FailureRetryInterval: 3000})
+	putStandaloneTask(registry, "app/discard.Handle", &bean.TaskOptions{Name: "discard", Cron: "0/10 * * * * ?", ExpireTime: 5000, FailureStrategy: bean.FailureDiscard, MaxRetryCount: 2})
+	putStandaloneTask(registry, "app/removed.Handle", &bean.TaskOptions{Name: "removed", Cron: "0/10 * * * * ?", ExpireTime: 5000, FailureStrategy: bean.FailureRetry, MaxRetryCount: 2})
+	service, added := newTestStandalone(registry, clock)
+	service.generate(start.Add(time.Second * 10))
+	if len(*added) != 3 {
+		t.Fatalf("all tasks should be generated, added: %d", len(*added))
+	}
+	discard, removed, retry := (*added)[0], (*added)[1], (*added)[2]
+	*added = nil
+
+	// 本地重试中的状态不触发失败策略
+	clock.now = start.Add(time.Second * 11)
+	service.onResult(&task.TaskResult{TaskLogId: retry.TaskLogId, State: task.EXECUTION_FAILED_RETRYING})
+	if len(*added) != 0 {
+		t.Fatalf("local retrying state should be ignored, added: %d", len(*added))
+	}
+	for i := 1; i <= 3; i++ {
+		service.onResult(&task.TaskResult{TaskLogId: retry.TaskLogId, State: task.EXECUTION_FAILED})
+	}
+	if len(*added) != 2 {
+		t.Fatalf("task should be retried MaxRetryCount times, added: %d", len(*added))
+	}
+	for i, params := range *added {
+		if params.RetryCount != i+1 || params.TaskLogId != retry.TaskLogId || params.ExecutionTime != clock.now.UnixMilli()+3000 || params.ScheduleTime != retry.ScheduleTime {
+			t.Errorf("unexpected retry params: %+v", params)
+		}
+	}
+	if _, ok := service.runs.Load(retry.TaskLogId); ok {
+		t.Errorf("run should be removed after the final failure")
+	}
+
+	*added = nil
+	result := &task.TaskResult{TaskLogId: discard.TaskLogId, State: task.EXECUTION_FAILED}
+	service.onResult(result)
+	if len(*added) != 0 || result.State != task.EXECUTION_FAILED_DISCARD {
+		t.Errorf("discard strategy should not retry, added: %d, state: %d", len(*added), result.State)
+	}
+	registry.Remove("app/removed.Handle")
+	service.onResult(&task.TaskResult{TaskLogId: removed.TaskLogId, State: task.EXECUTION_FAILED})
+	if len(*added) != 0 {
+		t.Errorf("removed task should not retry, added: %d", len(*added))
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+// Created in 2026-10-18 18:10.
+// @author Horace
+
+import (
+	"github.com/horacedh/cronjob-executor/bean"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// TaskRegistry 任务注册表，保存任务处理方法和任务配置，支持运行时并发修改
+type TaskRegistry interface {
+	// Get 获取任务处理方法和任务配置，method为包路径+方法名，不存在时返回nil
+	Get(method string) (*reflect.Value, *bean.TaskOptions)
+	// Find 根据任务方法或者任务名称查找任务，返回任务方法和任务配置
+	Find(name string) (string, *bean.TaskOptions)
+	// Options 获取任务配置的快照，key为包路径+方法名
+	Options() map[string]*bean.TaskOptions
+	// Put 添加或者替换任务
+	Put(method string, handler *reflect.Value, options *bean.TaskOptions)
+	// Remove 移除任务，返回任务是否存在
+	Remove(method string) bool
+	// Version 任务集合的版本号，每次修改后递增
+	Version() int64
+}
+
+// taskRegistryImpl 实现类
+type taskRegistryImpl struct {
+	mu sync.RWMutex
+	// handlers 任务处理方法集合，key为包路径+方法名，value为方法的反射值
+	handlers map[string]*reflect.Value
+	// taskOptions 任务配置集合，key为包路径+方法名，value为任务配置
+	taskOptions map[string]*bean.TaskOptions
+	// version 版本号
+	version atomic.Int64
+}
+
+// Get 获取任务处理方法和任务配置
+func (registry *taskRegistryImpl) Get(method string) (*reflect.Value, *bean.TaskOptions) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.handlers[method], registry.taskOptions[method]
+}
+
+// Find 根据任务方法或者任务名称查找任务
+func (registry *taskRegistryImpl) Find(name string) (string, *bean.TaskOptions) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	if options := registry.taskOptions[name]; options != nil {
+		return name, options
+	}
+	for method, options := range registry.taskOptions {
+		if options.Name == name {
+			return method, options
+		}
+	}
+	return "", nil
+}
+
+// Options 获取任务配置的快照
+func (registry *taskRegistryImpl) Options() map[string]*bean.TaskOptions {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	taskOptions := make(map[string]*bean.TaskOptions, len(registry.taskOptions))
+	for method, options := range registry.taskOptions {
+		taskOptions[method] = options
+	}
+	return taskOptions
+}
+
+// Put 添加或者替换任务，任务配置不会被原地修改，正在执行的任务仍然使用旧的配置
+func (registry *taskRegistryImpl) Put(method string, handler *reflect.Value, options *bean.TaskOptions) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.handlers[method] = handler
+	registry.taskOptions[method] = options
+	registry.version.Add(1)
+}
+
+// Remove 移除任务，正在执行的任务会继续执行完成
+func (registry *taskRegistryImpl) Remove(method string) bool {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.taskOptions[method]; !ok {
+		return false
+	}
+	delete(registry.handlers, method)
+	delete(registry.taskOptions, method)
+	registry.version.Add(1)
+	return true
+}
+
+// Version 任务集合的版本号
+func (registry *taskRegistryImpl) Version() int64 {
+	return registry.version.Load()
+}
+
+// NewTaskRegistry 创建任务注册表
+func NewTaskRegistry() TaskRegistry {
+	return &taskRegistryImpl{
+		handlers:    make(map[string]*reflect.Value),
+		taskOptions: make(map[string]*bean.TaskOptions),
+	}
+}
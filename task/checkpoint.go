@@ -0,0 +1,37 @@
+package task
+
+// Created in 2026-10-18 21:40.
+// @author Horace
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoCheckpointStore 没有配置检查点存储
+var ErrNoCheckpointStore = errors.New("checkpoint store not configured")
+
+// CheckpointKey 检查点的key，同一次逻辑执行（同一个任务、分片和调度时间槽）的多次本地重试共用一个检查点
+type CheckpointKey struct {
+	// TaskId 任务ID
+	TaskId int64 `json:"taskId"`
+	// Page 分片页码
+	Page int32 `json:"page"`
+	// ScheduleTime 调度时间槽，毫秒
+	ScheduleTime int64 `json:"scheduleTime"`
+}
+
+// String 转换为字符串，可以用作文件名
+func (key CheckpointKey) String() string {
+	return fmt.Sprintf("%d-%d-%d", key.TaskId, key.Page, key.ScheduleTime)
+}
+
+// CheckpointStore 检查点存储，保存处理方法的游标，游标的内容由处理方法自己定义
+type CheckpointStore interface {
+	// Load 加载检查点，不存在时返回nil
+	Load(key CheckpointKey) ([]byte, error)
+	// Save 保存检查点，覆盖已有的检查点
+	Save(key CheckpointKey, cursor []byte) error
+	// Delete 删除检查点，不存在时不返回错误
+	Delete(key CheckpointKey) error
+}
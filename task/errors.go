@@ -0,0 +1,115 @@
+package task
+
+// Created in 2026-10-18 22:40.
+// @author Horace
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorCategory 错误分类，调度器和下游工具据此决定是否重试以及如何展示
+type ErrorCategory string
+
+const (
+	// CategoryNone 未分类
+	CategoryNone ErrorCategory = ""
+	// CategoryRetryable 可以重试的错误，例如网络抖动、依赖服务暂时不可用
+	CategoryRetryable ErrorCategory = "retryable"
+	// CategoryPermanent 不可重试的错误，例如参数错误、数据不合法，重试也不会成功
+	CategoryPermanent ErrorCategory = "permanent"
+	// CategorySkipped 没有需要处理的数据，处理结果为成功
+	CategorySkipped ErrorCategory = "skipped"
+)
+
+// ErrNoWork 没有需要处理的数据，FromError映射为Skipped
+var ErrNoWork = errors.New("no work to do")
+
+// categoryError 带错误分类的错误
+type categoryError struct {
+	// category 错误分类
+	category ErrorCategory
+	// err 原始错误
+	err error
+}
+
+// Error 错误信息
+func (err *categoryError) Error() string {
+	return err.err.Error()
+}
+
+// Unwrap 获取原始错误
+func (err *categoryError) Unwrap() error {
+	return err.err
+}
+
+// Format 格式化，%+v时输出原始错误的详细信息，例如原始错误携带的堆栈
+func (err *categoryError) Format(state fmt.State, verb rune) {
+	if verb == 'v' && state.Flag('+') {
+		_, _ = fmt.Fprint(state, ErrorDetail(err.err))
+		return
+	}
+	_, _ = fmt.Fprintf(state, fmt.FormatString(state, verb), err.err)
+}
+
+// Retryable 标记错误为可以重试，err为nil时返回nil
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &categoryError{category: CategoryRetryable, err: err}
+}
+
+// Permanent 标记错误为不可重试，err为nil时返回nil
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &categoryError{category: CategoryPermanent, err: err}
+}
+
+// CategoryOf 获取错误的分类，错误链中没有分类时返回CategoryNone
+func CategoryOf(err error) ErrorCategory {
+	if errors.Is(err, ErrNoWork) {
+		return CategorySkipped
+	}
+	var categorized *categoryError
+	if errors.As(err, &categorized) {
+		return categorized.category
+	}
+	return CategoryNone
+}
+
+// FromError 将处理方法的错误映射为处理结果，nil为成功，ErrNoWork为没有需要处理的数据，其他错误为失败并带上错误分类
+func FromError(err error) *HandlerResult {
+	if err == nil {
+		return Success()
+	}
+	category := CategoryOf(err)
+	if category == CategorySkipped {
+		return Skipped(err.Error())
+	}
+	return FailedWithCode(1, ErrorDetail(err), category)
+}
+
+// ErrorDetail 获取错误的详细信息，使用%+v输出完整的错误链，错误携带堆栈时包含堆栈。
+// 外层错误没有实现fmt.Formatter时（例如fmt.Errorf包装的错误），%+v不会输出内层错误的堆栈，此时追加错误链中第一个实现了fmt.Formatter的错误的详细信息
+func ErrorDetail(err error) string {
+	if err == nil {
+		return ""
+	}
+	detail := fmt.Sprintf("%+v", err)
+	if _, ok := err.(fmt.Formatter); ok {
+		return detail
+	}
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		if _, ok := cause.(fmt.Formatter); ok {
+			if inner := fmt.Sprintf("%+v", cause); inner != cause.Error() && !strings.Contains(detail, inner) {
+				detail += "\n" + inner
+			}
+			break
+		}
+	}
+	return detail
+}
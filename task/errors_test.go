@@ -0,0 +1,99 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Created in 2026-10-18 22:55.
+// @author Horace
+
+// TestFromError 测试错误映射为处理结果和错误分类
+func TestFromError(t *testing.T) {
+	if result := FromError(nil); !result.IsSuccess() || result.Category != CategoryNone {
+		t.Errorf("nil error should be success, result: %+v", result)
+	}
+	if result := FromError(fmt.Errorf("orders: %w", ErrNoWork)); !result.IsSuccess() || result.Category != CategorySkipped {
+		t.Errorf("no work should be skipped, result: %+v", result)
+	}
+
+	cause := errors.New("invalid order")
+	err := fmt.Errorf("process: %w", Permanent(cause))
+	if result := FromError(err); result.IsSuccess() || result.Category != CategoryPermanent || result.Msg != "process: invalid order" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("categorized error should unwrap to the cause")
+	}
+	if result := FromError(Retryable(errors.New("timeout"))); result.Category != CategoryRetryable {
+		t.Errorf("unexpected category: %s", result.Category)
+	}
+	if result := FromError(errors.New("unknown")); result.IsSuccess() || result.Category != CategoryNone {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if Retryable(nil) != nil || Permanent(nil) != nil {
+		t.Errorf("nil error should stay nil")
+	}
+}
+
+// TestHandlerResultOutput 测试处理结果的输出数据、指标和计数
+func TestHandlerResultOutput(t *testing.T) {
+	result := Success().WithOutput(map[string]int{"orders": 3}).WithMetric("rate", 1.5).WithCounter("rows", 2).WithCounter("rows", 3)
+	if result.Counters["rows"] != 5 || result.Metrics["rate"] != 1.5 || result.Output == nil {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result = FailedWithCode(0, "failed", CategoryPermanent); result.IsSuccess() || result.Code != 1 {
+		t.Errorf("code 0 should be mapped to 1, result: %+v", result)
+	}
+}
+
+// stackError 模拟携带堆栈的错误
+type stackError struct {
+	msg string
+}
+
+// Error 错误信息
+func (err *stackError) Error() string {
+	return err.msg
+}
+
+// Format %+v时输出堆栈
+func (err *stackError) Format(state fmt.State, verb rune) {
+	if verb == 'v' && state.Flag('+') {
+		_, _ = fmt.Fprintf(state, "%s\nmain.process\n\tmain.go:10", err.msg)
+		return
+	}
+	_, _ = fmt.Fprint(state, err.msg)
+}
+
+// TestErrorHandler 测试返回error的处理器，失败原因包含完整的错误链和堆栈
+func TestErrorHandler(t *testing.T) {
+	handler := HandleError(errorHandlerFunc(func(params *TaskParams) error {
+		if params.Params == "" {
+			return nil
+		}
+		return fmt.Errorf("process %s: %w", params.Params, Permanent(&stackError{msg: "connection refused"}))
+	}))
+	if result := handler.Handle(&TaskParams{}); result == nil || !result.IsSuccess() || result.Msg != "success" {
+		t.Errorf("nil error should map to success, result: %+v", result)
+	}
+	result := handler.Handle(&TaskParams{Params: "orders"})
+	if result.IsSuccess() || result.Category != CategoryPermanent {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.Msg != "process orders: connection refused\nconnection refused\nmain.process\n\tmain.go:10" {
+		t.Errorf("error detail should contain the chain and stack, msg: %q", result.Msg)
+	}
+	if _, ok := handler.(interface{ Unwrap() any }).Unwrap().(errorHandlerFunc); !ok {
+		t.Errorf("adapter should unwrap to the original handler")
+	}
+}
+
+// errorHandlerFunc 函数形式的ErrorHandler
+type errorHandlerFunc func(params *TaskParams) error
+
+// Handle 任务处理方法
+func (handler errorHandlerFunc) Handle(params *TaskParams) error {
+	return handler(params)
+}
@@ -0,0 +1,149 @@
+package task
+
+// Created in 2026-10-18 21:05.
+// @author Horace
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// Shard 分片信息，用于路由策略为分片时计算当前分片负责处理的数据。
+// 各个方法只依赖分片序号和分片总数，不保存状态，分片总数在两次执行之间变化时，同一次执行的所有分片仍然不重不漏地覆盖全部数据
+type Shard struct {
+	// Index 分片序号，从0开始
+	Index int64
+	// Total 分片总数，小于等于0时视为不分片，当前分片负责全部数据
+	Total int64
+}
+
+// normalize 分片总数小于等于0时视为只有一个分片，避免除以0
+func (shard Shard) normalize() Shard {
+	if shard.Total <= 0 {
+		return Shard{Index: 0, Total: 1}
+	}
+	return shard
+}
+
+// NewShard 根据调度器下发的页码和总页数创建分片，页码从1开始，总页数小于等于0时视为不分片
+func NewShard(page, total int32) (Shard, error) {
+	if total <= 0 {
+		total = 1
+		if page <= 0 {
+			page = 1
+		}
+	}
+	if page < 1 || page > total {
+		return Shard{}, fmt.Errorf("invalid shard, page: %d, total: %d", page, total)
+	}
+	return Shard{Index: int64(page - 1), Total: int64(total)}, nil
+}
+
+// Shard 获取任务参数中的分片信息
+func (params *TaskParams) Shard() (Shard, error) {
+	return NewShard(params.Page, params.Total)
+}
+
+// OwnsID 按取模的方式判断数字ID是否由当前分片处理，适合ID连续或均匀分布的数据，分片总数变化后大部分数据会换分片
+func (shard Shard) OwnsID(id int64) bool {
+	shard = shard.normalize()
+	mod := id % shard.Total
+	if mod < 0 {
+		mod += shard.Total
+	}
+	return mod == shard.Index
+}
+
+// OwnsKey 按一致性哈希判断字符串key是否由当前分片处理，分片总数从n增加到n+1时，只有约1/(n+1)的key移动到新增的分片
+func (shard Shard) OwnsKey(key string) bool {
+	shard = shard.normalize()
+	return jumpHash(hashKey(key), shard.Total) == shard.Index
+}
+
+// Range 将左闭右开的ID区间[start, end)按分片总数切分为连续的子区间，返回当前分片负责的子区间，
+// 区间长度不能整除时前面的分片多分一个，分片总数大于区间长度时部分分片得到空区间(from == to)
+func (shard Shard) Range(start, end int64) (from int64, to int64) {
+	if end <= start {
+		return start, start
+	}
+	shard = shard.normalize()
+	length := uint64(end - start)
+	size, remainder := length/uint64(shard.Total), length%uint64(shard.Total)
+	index := uint64(shard.Index)
+	offset := index*size + min(index, remainder)
+	if index < remainder {
+		size++
+	}
+	return start + int64(offset), start + int64(offset+size)
+}
+
+// TimeWindow 将左闭右开的时间窗口[start, end)按分片总数切分，返回当前分片负责的时间窗口，切分精度为纳秒
+func (shard Shard) TimeWindow(start, end time.Time) (from time.Time, to time.Time) {
+	fromOffset, toOffset := shard.Range(0, int64(end.Sub(start)))
+	return start.Add(time.Duration(fromOffset)), start.Add(time.Duration(toOffset))
+}
+
+// Iterate 创建当前分片负责的ID区间的分批迭代器，每批最多batchSize个ID
+func (shard Shard) Iterate(start, end int64, batchSize int64) *RangeIterator {
+	from, to := shard.Range(start, end)
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	return &RangeIterator{next: from, end: to, batchSize: batchSize}
+}
+
+// RangeIterator 分片区间的分批迭代器，用法：
+//
+//	for iterator.Next() {
+//		from, to := iterator.Batch()
+//	}
+type RangeIterator struct {
+	// next 下一批的起始ID
+	next int64
+	// end 区间结束ID，不包含
+	end int64
+	// batchSize 每批的ID数量
+	batchSize int64
+	// from 当前批次的起始ID
+	from int64
+	// to 当前批次的结束ID，不包含
+	to int64
+}
+
+// Next 移动到下一批，没有剩余的ID时返回false
+func (iterator *RangeIterator) Next() bool {
+	if iterator.next >= iterator.end {
+		return false
+	}
+	iterator.from = iterator.next
+	iterator.to = iterator.end
+	if iterator.end-iterator.from > iterator.batchSize {
+		iterator.to = iterator.from + iterator.batchSize
+	}
+	iterator.next = iterator.to
+	return true
+}
+
+// Batch 当前批次左闭右开的ID区间[from, to)
+func (iterator *RangeIterator) Batch() (from int64, to int64) {
+	return iterator.from, iterator.to
+}
+
+// hashKey 计算字符串key的64位哈希值
+func hashKey(key string) uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key))
+	return hash.Sum64()
+}
+
+// jumpHash 跳跃一致性哈希（Lamping & Veach），不需要哈希环，分片总数变化时只移动最少的key
+func jumpHash(key uint64, buckets int64) int64 {
+	var bucket, next int64 = -1, 0
+	for next < buckets {
+		bucket = next
+		key = key*2862933555777941757 + 1
+		next = int64(float64(bucket+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return bucket
+}
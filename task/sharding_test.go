@@ -0,0 +1,134 @@
+package task
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-18 21:20.
+// @author Horace
+
+// shards 创建分片总数为total的全部分片
+func shards(t *testing.T, total int32) []Shard {
+	var result []Shard
+	for page := int32(1); page <= total; page++ {
+		shard, err := (&TaskParams{Page: page, Total: total}).Shard()
+		if err != nil {
+			t.Fatalf("create shard failed, err: %v", err)
+		}
+		result = append(result, shard)
+	}
+	return result
+}
+
+// TestNewShard 测试根据页码和总页数创建分片
+func TestNewShard(t *testing.T) {
+	if shard, err := NewShard(0, 0); err != nil || shard != (Shard{Index: 0, Total: 1}) {
+		t.Errorf("unsharded task should be a single shard, shard: %v, err: %v", shard, err)
+	}
+	if shard, err := NewShard(3, 4); err != nil || shard != (Shard{Index: 2, Total: 4}) {
+		t.Errorf("unexpected shard: %v, err: %v", shard, err)
+	}
+	for _, params := range [][2]int32{{0, 3}, {4, 3}, {-1, 2}} {
+		if _, err := NewShard(params[0], params[1]); err == nil {
+			t.Errorf("invalid page should fail, page: %d, total: %d", params[0], params[1])
+		}
+	}
+
+	// 零值的分片视为不分片，负责全部数据
+	var zero Shard
+	if from, to := zero.Range(100, 110); !zero.OwnsID(7) || !zero.OwnsKey("order-1") || from != 100 || to != 110 {
+		t.Errorf("zero shard should own all data, range: [%d, %d)", from, to)
+	}
+}
+
+// TestShardOwnership 测试分片总数变化前后，每个ID和key都只由一个分片处理，一致性哈希只把key移动到新增的分片
+func TestShardOwnership(t *testing.T) {
+	owner := func(shards []Shard, owns func(Shard) bool) int64 {
+		found := int64(-1)
+		for _, shard := range shards {
+			if owns(shard) {
+				if found >= 0 {
+					t.Fatalf("owned by shard %d and %d", found, shard.Index)
+				}
+				found = shard.Index
+			}
+		}
+		if found < 0 {
+			t.Fatalf("not owned by any shard")
+		}
+		return found
+	}
+
+	before, after := shards(t, 3), shards(t, 4)
+	for id := int64(-50); id < 50; id++ {
+		owner(before, func(shard Shard) bool { return shard.OwnsID(id) })
+		owner(after, func(shard Shard) bool { return shard.OwnsID(id) })
+	}
+
+	var moved int
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("order-%d", i)
+		from := owner(before, func(shard Shard) bool { return shard.OwnsKey(key) })
+		to := owner(after, func(shard Shard) bool { return shard.OwnsKey(key) })
+		if from != to {
+			if to != 3 {
+				t.Fatalf("key %s moved from %d to existing shard %d", key, from, to)
+			}
+			moved++
+		}
+	}
+	if moved < 2000 || moved > 3000 {
+		t.Errorf("about 1/4 keys should move to the new shard, moved: %d", moved)
+	}
+}
+
+// TestShardRange 测试区间和时间窗口切分在不同分片总数下连续、不重叠地覆盖全部区间
+func TestShardRange(t *testing.T) {
+	for _, total := range []int32{1, 2, 3, 7, 12} {
+		next := int64(100)
+		for _, shard := range shards(t, total) {
+			from, to := shard.Range(100, 110)
+			if from != next || to < from || to-from > 1+10/int64(total) {
+				t.Fatalf("unexpected range [%d, %d), total: %d, shard: %d", from, to, total, shard.Index)
+			}
+			next = to
+		}
+		if next != 110 {
+			t.Errorf("range not covered, total: %d, end: %d", total, next)
+		}
+	}
+
+	start := time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)
+	end := start.Add(time.Hour * 24)
+	next := start
+	for _, shard := range shards(t, 5) {
+		from, to := shard.TimeWindow(start, end)
+		if !from.Equal(next) || to.Sub(from) != time.Hour*24/5 {
+			t.Fatalf("unexpected time window [%v, %v), shard: %d", from, to, shard.Index)
+		}
+		next = to
+	}
+	if !next.Equal(end) {
+		t.Errorf("time window not covered, end: %v", next)
+	}
+}
+
+// TestRangeIterator 测试分批迭代当前分片负责的区间
+func TestRangeIterator(t *testing.T) {
+	shard := Shard{Index: 1, Total: 3}
+	iterator := shard.Iterate(0, 20, 3)
+	var batches [][2]int64
+	for iterator.Next() {
+		from, to := iterator.Batch()
+		batches = append(batches, [2]int64{from, to})
+	}
+	expected := [][2]int64{{7, 10}, {10, 13}, {13, 14}}
+	if fmt.Sprint(batches) != fmt.Sprint(expected) {
+		t.Errorf("unexpected batches: %v", batches)
+	}
+	if (Shard{Index: 5, Total: 6}).Iterate(0, 3, 10).Next() {
+		t.Errorf("empty range should not have batches")
+	}
+}
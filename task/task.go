@@ -3,6 +3,11 @@ package task
 // Created in 2025-03-18 20:15.
 // @author Horace
 
+import (
+	"context"
+	"encoding/json"
+)
+
 // TaskParams 任务参数
 type TaskParams struct {
 	// Page 页码
@@ -27,14 +32,108 @@ type TaskParams struct {
 	ReceivedDispatcherTime int64 `json:"receivedDispatcherTime"`
 	// Params 任务自定义参数
 	Params string `json:"params"`
+	// ScheduleTime 调度时间槽，毫秒，单机模式下按照失败策略重试时保持为第一次的执行时间，调度器不下发此字段
+	ScheduleTime int64 `json:"scheduleTime"`
+	// RetryCount 单机模式下按照失败策略重试的次数，第一次执行为0，用于判断失败是否为最终失败，调度器不下发此字段
+	RetryCount int `json:"-"`
+	// ctx 任务上下文，携带链路追踪信息
+	ctx context.Context
+	// checkpoints 检查点存储
+	checkpoints CheckpointStore
+	// checkpointed 本次执行是否加载到或者保存过检查点
+	checkpointed bool
+}
+
+// Context 获取任务上下文，处理方法中的数据库、RPC等调用使用此上下文即可加入同一条链路
+func (params *TaskParams) Context() context.Context {
+	if params.ctx == nil {
+		return context.Background()
+	}
+	return params.ctx
+}
+
+// SetContext 设置任务上下文
+func (params *TaskParams) SetContext(ctx context.Context) {
+	params.ctx = ctx
+}
+
+// SetCheckpointStore 设置检查点存储
+func (params *TaskParams) SetCheckpointStore(store CheckpointStore) {
+	params.checkpoints = store
+}
+
+// CheckpointKey 获取本次执行的检查点key。调度器不下发调度时间槽，连接调度器时使用执行时间，
+// 只有执行器本地重试（LocalRetryCount）可以恢复检查点，调度器按照失败策略重试时执行时间变化，作为新的一次执行从头开始；
+// 单机模式按照失败策略重试时保持调度时间槽，同样可以恢复
+func (params *TaskParams) CheckpointKey() CheckpointKey {
+	scheduleTime := params.ScheduleTime
+	if scheduleTime == 0 {
+		scheduleTime = params.ExecutionTime
+	}
+	return CheckpointKey{TaskId: params.TaskId, Page: params.Page, ScheduleTime: scheduleTime}
+}
+
+// LoadCheckpoint 加载上一次失败时保存的检查点，没有检查点时返回nil，处理方法从返回的游标处继续执行
+func (params *TaskParams) LoadCheckpoint() ([]byte, error) {
+	if params.checkpoints == nil {
+		return nil, nil
+	}
+	cursor, err := params.checkpoints.Load(params.CheckpointKey())
+	if cursor != nil {
+		params.checkpointed = true
+	}
+	return cursor, err
+}
+
+// SaveCheckpoint 保存检查点，任务失败后本地重试时可以通过LoadCheckpoint获取，任务执行成功后自动删除，恢复的范围见CheckpointKey
+func (params *TaskParams) SaveCheckpoint(cursor []byte) error {
+	if params.checkpoints == nil {
+		return ErrNoCheckpointStore
+	}
+	params.checkpointed = true
+	return params.checkpoints.Save(params.CheckpointKey(), cursor)
+}
+
+// ClearCheckpoint 删除检查点
+func (params *TaskParams) ClearCheckpoint() error {
+	if params.checkpoints == nil {
+		return nil
+	}
+	params.checkpointed = false
+	return params.checkpoints.Delete(params.CheckpointKey())
 }
 
+// HasCheckpoint 本次执行是否加载到或者保存过检查点
+func (params *TaskParams) HasCheckpoint() bool {
+	return params.checkpointed
+}
+
+// 执行类型
+const (
+	// ExeTypeNormal 常规任务调度
+	ExeTypeNormal int32 = 0
+	// ExeTypeManual 手动立即执行，包括管理后台立即执行和执行器本地立即执行
+	ExeTypeManual int32 = 1
+	// ExeTypeExpired 过期执行
+	ExeTypeExpired int32 = 2
+	// ExeTypeChain 上游任务在本地触发的后续任务
+	ExeTypeChain int32 = 3
+)
+
 // HandlerResult 任务处理结果
 type HandlerResult struct {
 	// Code 处理结果编码
 	Code int32
 	// Msg 处理结果描述信息
 	Msg string
+	// Category 错误分类，成功时可以为CategorySkipped表示没有需要处理的数据
+	Category ErrorCategory
+	// Output 结构化的输出数据，序列化为JSON后发送给调度器
+	Output any
+	// Metrics 处理方法自定义的指标，例如处理速度
+	Metrics map[string]float64
+	// Counters 处理方法自定义的计数，例如处理条数
+	Counters map[string]int64
 }
 
 // IsSuccess 是否成功
@@ -42,22 +141,86 @@ func (result *HandlerResult) IsSuccess() bool {
 	return result.Code == 0
 }
 
+// WithOutput 设置结构化的输出数据
+func (result *HandlerResult) WithOutput(output any) *HandlerResult {
+	result.Output = output
+	return result
+}
+
+// WithMetric 设置自定义指标
+func (result *HandlerResult) WithMetric(name string, value float64) *HandlerResult {
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]float64)
+	}
+	result.Metrics[name] = value
+	return result
+}
+
+// WithCounter 累加自定义计数
+func (result *HandlerResult) WithCounter(name string, delta int64) *HandlerResult {
+	if result.Counters == nil {
+		result.Counters = make(map[string]int64)
+	}
+	result.Counters[name] += delta
+	return result
+}
+
 // Success 成功
 func Success() *HandlerResult {
 	return &HandlerResult{Code: 0, Msg: "success"}
 }
 
+// Skipped 成功，但是没有需要处理的数据，调度器可以据此区分"没有数据"和正常处理完成
+func Skipped(msg string) *HandlerResult {
+	return &HandlerResult{Code: 0, Msg: msg, Category: CategorySkipped}
+}
+
 // Failed 失败
 func Failed(msg string) *HandlerResult {
 	return &HandlerResult{Code: 1, Msg: msg}
 }
 
+// FailedWithCode 使用自定义编码的失败，code为0时使用1，避免失败结果被当作成功
+func FailedWithCode(code int32, msg string, category ErrorCategory) *HandlerResult {
+	if code == 0 {
+		code = 1
+	}
+	return &HandlerResult{Code: code, Msg: msg, Category: category}
+}
+
 // TaskHandler 任务处理器接口
 type TaskHandler interface {
 	// Handle 任务处理方法
 	Handle(params *TaskParams) *HandlerResult
 }
 
+// ErrorHandler 返回error的任务处理器接口，通过HandleError转换为TaskHandler后添加
+type ErrorHandler interface {
+	// Handle 任务处理方法，返回nil表示成功，ErrNoWork表示没有需要处理的数据，其他错误表示失败
+	Handle(params *TaskParams) error
+}
+
+// errorHandlerAdapter 将ErrorHandler转换为TaskHandler
+type errorHandlerAdapter struct {
+	// handler 原始的处理器
+	handler ErrorHandler
+}
+
+// HandleError 将返回error的处理器转换为TaskHandler，任务方法的名称仍然使用原始处理器的类型名
+func HandleError(handler ErrorHandler) TaskHandler {
+	return &errorHandlerAdapter{handler: handler}
+}
+
+// Handle 调用原始的处理器，并将返回的错误映射为处理结果
+func (adapter *errorHandlerAdapter) Handle(params *TaskParams) *HandlerResult {
+	return FromError(adapter.handler.Handle(params))
+}
+
+// Unwrap 获取原始的处理器，用于生成任务方法的名称
+func (adapter *errorHandlerAdapter) Unwrap() any {
+	return adapter.handler
+}
+
 // TaskLogState 任务日志状态
 type TaskLogState int32
 
@@ -100,4 +263,48 @@ type TaskResult struct {
 	ElapsedTime int `json:"elapsedTime"`
 	// Address 执行器地址
 	Address string `json:"address"`
+	// Code 处理结果编码
+	Code int32 `json:"code,omitempty"`
+	// Category 错误分类
+	Category ErrorCategory `json:"category,omitempty"`
+	// Output 处理方法的结构化输出数据
+	Output json.RawMessage `json:"output,omitempty"`
+	// Metrics 处理方法自定义的指标
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+	// Counters 处理方法自定义的计数
+	Counters map[string]int64 `json:"counters,omitempty"`
+	// Attempts 开启本地重试时每一次执行的记录
+	Attempts []TaskAttempt `json:"attempts,omitempty"`
+	// ctx 任务上下文，携带链路追踪信息
+	ctx context.Context
+}
+
+// TaskAttempt 本地重试时一次执行的记录
+type TaskAttempt struct {
+	// Attempt 第几次执行，从1开始
+	Attempt int `json:"attempt"`
+	// State 本次执行的状态
+	State TaskLogState `json:"state"`
+	// FailedReason 失败原因
+	FailedReason string `json:"failedReason,omitempty"`
+	// StartTime 开始执行时间，毫秒
+	StartTime int64 `json:"startTime"`
+	// ElapsedTime 耗时，毫秒
+	ElapsedTime int `json:"elapsedTime"`
+}
+
+// RetryClassifier 本地重试的错误分类器，返回失败是否可以在本地重试，处理方法发生异常或者返回空时result为失败原因构造的失败结果
+type RetryClassifier func(params *TaskParams, result *HandlerResult) bool
+
+// Context 获取任务结果的上下文
+func (result *TaskResult) Context() context.Context {
+	if result.ctx == nil {
+		return context.Background()
+	}
+	return result.ctx
+}
+
+// SetContext 设置任务结果的上下文
+func (result *TaskResult) SetContext(ctx context.Context) {
+	result.ctx = ctx
 }
@@ -0,0 +1,79 @@
+package tracing
+
+// Created in 2026-10-18 11:05.
+// @author Horace
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"sync/atomic"
+)
+
+// instrumentationName 埋点名称
+const instrumentationName = "github.com/horacedh/cronjob-executor"
+
+// 链路追踪中使用的属性名称
+const (
+	// AttrTaskLogId 任务日志ID
+	AttrTaskLogId = attribute.Key("cronjob.task_log_id")
+	// AttrTaskId 任务ID
+	AttrTaskId = attribute.Key("cronjob.task_id")
+	// AttrMethod 任务方法
+	AttrMethod = attribute.Key("cronjob.method")
+	// AttrState 任务执行状态
+	AttrState = attribute.Key("cronjob.state")
+)
+
+// 链路追踪中使用的Span名称
+const (
+	// SpanDispatch 接收调度器的任务分发请求
+	SpanDispatch = "cronjob.dispatch"
+	// SpanQueueWait 任务在调度队列中等待执行
+	SpanQueueWait = "cronjob.queue_wait"
+	// SpanInvoke 执行任务处理方法
+	SpanInvoke = "cronjob.invoke"
+	// SpanSendResult 向调度器发送任务结果
+	SpanSendResult = "cronjob.send_result"
+)
+
+// tracerProvider 自定义的TracerProvider，未设置时使用otel全局的TracerProvider
+var tracerProvider atomic.Pointer[trace.TracerProvider]
+
+// propagator W3C traceparent 传播器
+var propagator = propagation.TraceContext{}
+
+// SetTracerProvider 设置执行器使用的TracerProvider，测试时可以传入使用内存导出器的TracerProvider
+func SetTracerProvider(provider trace.TracerProvider) {
+	tracerProvider.Store(&provider)
+}
+
+// Tracer 获取执行器的Tracer
+func Tracer() trace.Tracer {
+	if provider := tracerProvider.Load(); provider != nil {
+		return (*provider).Tracer(instrumentationName)
+	}
+	return otel.GetTracerProvider().Tracer(instrumentationName)
+}
+
+// Extract 从请求头中提取W3C traceparent，没有时返回原上下文
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject 将上下文中的Span信息以W3C traceparent格式写入请求头
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// TaskAttributes 构建任务相关的Span属性，所有Span都通过TaskLogId关联
+func TaskAttributes(taskLogId int64, taskId int64, method string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttrTaskLogId.Int64(taskLogId),
+		AttrTaskId.Int64(taskId),
+		AttrMethod.String(method),
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package utils
+
+// Created in 2026-10-18 19:55.
+// @author Horace
+
+import (
+	logger "github.com/cihub/seelog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SignalNotifyDrain 监听摘流和恢复的信号，kill -USR1 pid 开始摘流，kill -USR2 pid 恢复
+func SignalNotifyDrain(drain func(), resume func()) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for s := range c {
+			logger.Info("received signal is ", s)
+			if s == syscall.SIGUSR1 {
+				drain()
+			} else {
+				resume()
+			}
+		}
+	}()
+}
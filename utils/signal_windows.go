@@ -0,0 +1,10 @@
+//go:build windows
+
+package utils
+
+// Created in 2026-10-18 19:55.
+// @author Horace
+
+// SignalNotifyDrain Windows不支持USR1和USR2信号，只能通过接口或者管理接口摘流和恢复
+func SignalNotifyDrain(drain func(), resume func()) {
+}
@@ -14,6 +14,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -76,6 +77,23 @@ func GetLocalIP() string {
 	return "127.0.0.1"
 }
 
+// GetLoadAverage 获取系统最近1分钟的平均负载，只支持Linux，无法获取时返回-1
+func GetLoadAverage() float64 {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return -1
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return -1
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return -1
+	}
+	return load
+}
+
 // ToJsonString 转换成json字符串
 func ToJsonString(data interface{}) string {
 	jsonString, err := json.Marshal(data)
@@ -134,3 +152,19 @@ func Sign(signKey string, token string, times string, body string, params map[st
 	hash := md5.Sum([]byte(sb.String()))
 	return fmt.Sprintf("%x", hash)
 }
+
+// WaitTimeout 等待WaitGroup完成，超时返回false
+func WaitTimeout(waitGroup *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		waitGroup.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
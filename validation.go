@@ -0,0 +1,272 @@
+package cronjob
+
+// Created in 2026-10-18 16:05.
+// @author Horace
+
+import (
+	"errors"
+	"fmt"
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/cron"
+	"github.com/horacedh/cronjob-executor/task"
+	"reflect"
+	"strings"
+)
+
+// TaskDefinition 任务定义，用于批量添加任务
+type TaskDefinition struct {
+	// Handler 任务处理器
+	Handler task.TaskHandler
+	// Options 任务配置
+	Options bean.TaskOptions
+}
+
+// ValidateExecutorOptions 校验执行器配置，一次性返回所有问题
+func ValidateExecutorOptions(options *bean.ExecutorOptions) error {
+	if options == nil {
+		return errors.New("executor options is nil")
+	}
+
+	var errs []error
+	if options.AppName == "" {
+		errs = append(errs, errors.New("appName is required"))
+	}
+
+	// 单机模式不需要连接调度器，只需要应用名
+	if !options.Standalone {
+		if options.Address == "" {
+			errs = append(errs, errors.New("address is required"))
+		}
+		if options.SignKey == "" {
+			errs = append(errs, errors.New("signKey is required"))
+		}
+		if options.Tenant == "" {
+			errs = append(errs, errors.New("tenant is required"))
+		}
+		if options.AppDesc == "" {
+			errs = append(errs, errors.New("appDesc is required"))
+		}
+	}
+	if options.RegisterInterval < 0 {
+		errs = append(errs, fmt.Errorf("registerInterval %dms must not be negative", options.RegisterInterval))
+	}
+	if options.HeartbeatInterval < 0 {
+		errs = append(errs, fmt.Errorf("heartbeatInterval %dms must not be negative", options.HeartbeatInterval))
+	}
+	if options.ShutdownTimeout < 0 {
+		errs = append(errs, fmt.Errorf("shutdownTimeout %dms must not be negative", options.ShutdownTimeout))
+	}
+	if options.DedupTTL < 0 {
+		errs = append(errs, fmt.Errorf("dedupTtl %dms must not be negative", options.DedupTTL))
+	}
+	if options.OrphanStrategy < bean.OrphanIgnore || options.OrphanStrategy > bean.OrphanRetire {
+		errs = append(errs, fmt.Errorf("unknown orphanStrategy %d", options.OrphanStrategy))
+	}
+	if options.CheckpointStore < bean.CheckpointFile || options.CheckpointStore > bean.CheckpointOpenApi {
+		errs = append(errs, fmt.Errorf("unknown checkpointStore %d", options.CheckpointStore))
+	} else if options.CheckpointStore == bean.CheckpointOpenApi && options.Standalone {
+		errs = append(errs, errors.New("checkpointStore openapi is not supported in standalone mode"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid executor options: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// ValidateTaskOptions 校验任务配置，未设置的字段按照默认值校验，一次性返回所有问题
+func ValidateTaskOptions(options bean.TaskOptions) error {
+	setDefaultTaskOptions(&options)
+	if errs := validateTaskOptions(&options); len(errs) > 0 {
+		return fmt.Errorf("invalid task options, name: %s: %w", options.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// setDefaultExecutorOptions 设置执行器配置的默认值
+func setDefaultExecutorOptions(options *bean.ExecutorOptions) {
+	if options.Tag == "" {
+		options.Tag = "common"
+	}
+	if options.RegisterInterval == 0 {
+		options.RegisterInterval = bean.DefaultRegisterInterval
+	}
+	if options.HeartbeatInterval == 0 {
+		options.HeartbeatInterval = bean.DefaultHeartbeatInterval
+	}
+	if options.ShutdownTimeout == 0 {
+		options.ShutdownTimeout = bean.DefaultShutdownTimeout
+	}
+	if options.DedupTTL == 0 {
+		options.DedupTTL = bean.DefaultDedupTTL
+	}
+	if options.CheckpointDir == "" {
+		options.CheckpointDir = bean.DefaultCheckpointDir
+	}
+}
+
+// setDefaultTaskOptions 设置任务配置的默认值
+func setDefaultTaskOptions(options *bean.TaskOptions) {
+	if options.RouterStrategy == 0 {
+		options.RouterStrategy = bean.RANDOM
+	}
+	if options.ExpiredStrategy == 0 {
+		options.ExpiredStrategy = bean.ExpiredExecute
+	}
+	if options.ExpireTime == 0 {
+		options.ExpireTime = 3 * 60 * 1000
+	}
+	if options.FailureStrategy == 0 {
+		options.FailureStrategy = bean.FailureRetry
+	}
+	if options.MaxRetryCount == 0 {
+		options.MaxRetryCount = 5
+	}
+	if options.FailureRetryInterval == 0 {
+		options.FailureRetryInterval = 5000
+	}
+	if options.Timeout == 0 {
+		options.Timeout = 10000
+	}
+	if options.LocalRetryCount > 0 {
+		if options.LocalRetryInterval == 0 {
+			options.LocalRetryInterval = 1000
+		}
+		if options.LocalRetryMultiplier == 0 {
+			options.LocalRetryMultiplier = 2
+		}
+		if options.LocalRetryMaxInterval == 0 {
+			options.LocalRetryMaxInterval = 30 * 1000
+		}
+	}
+}
+
+// validateTaskOptions 校验已经设置默认值的任务配置
+func validateTaskOptions(options *bean.TaskOptions) []error {
+	var errs []error
+	if options.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if options.Cron == "" {
+		errs = append(errs, errors.New("cron is required"))
+	} else if err := cron.Validate(options.Cron); err != nil {
+		errs = append(errs, err)
+	}
+	if options.RouterStrategy != bean.RANDOM && options.RouterStrategy != bean.SHARDING {
+		errs = append(errs, fmt.Errorf("unknown routerStrategy %d", options.RouterStrategy))
+	}
+	if options.ExpiredStrategy != bean.ExpiredDiscard && options.ExpiredStrategy != bean.ExpiredExecute {
+		errs = append(errs, fmt.Errorf("unknown expiredStrategy %d", options.ExpiredStrategy))
+	}
+	if options.ExpireTime < 0 || options.ExpireTime > bean.MaxExpireTime {
+		errs = append(errs, fmt.Errorf("expireTime %dms out of range [0, %d]", options.ExpireTime, bean.MaxExpireTime))
+	}
+	if options.FailureStrategy != bean.FailureRetry && options.FailureStrategy != bean.FailureDiscard {
+		errs = append(errs, fmt.Errorf("unknown failureStrategy %d", options.FailureStrategy))
+	}
+	if options.MaxRetryCount < 0 {
+		errs = append(errs, fmt.Errorf("maxRetryCount %d must not be negative", options.MaxRetryCount))
+	}
+	if options.FailureRetryInterval < 0 {
+		errs = append(errs, fmt.Errorf("failureRetryInterval %dms must not be negative", options.FailureRetryInterval))
+	}
+	if options.Timeout < 0 || options.Timeout > bean.MaxTimeout {
+		errs = append(errs, fmt.Errorf("timeout %dms out of range [0, %d]", options.Timeout, bean.MaxTimeout))
+	}
+	if options.LocalRetryCount < 0 {
+		errs = append(errs, fmt.Errorf("localRetryCount %d must not be negative", options.LocalRetryCount))
+	}
+	if options.LocalRetryInterval < 0 {
+		errs = append(errs, fmt.Errorf("localRetryInterval %dms must not be negative", options.LocalRetryInterval))
+	}
+	if options.LocalRetryMultiplier < 0 {
+		errs = append(errs, fmt.Errorf("localRetryMultiplier %d must not be negative", options.LocalRetryMultiplier))
+	}
+	if options.LocalRetryMaxInterval < 0 {
+		errs = append(errs, fmt.Errorf("localRetryMaxInterval %dms must not be negative", options.LocalRetryMaxInterval))
+	}
+	if options.ChainMode != bean.ChainLocal && options.ChainMode != bean.ChainScheduler {
+		errs = append(errs, fmt.Errorf("unknown chainMode %d", options.ChainMode))
+	}
+	return errs
+}
+
+// resolveHandler 获取任务处理器的唯一key（包路径+方法名）和Handle方法的反射值
+func resolveHandler(appName string, handler task.TaskHandler) (string, *reflect.Value, error) {
+	if handler == nil {
+		return "", nil, errors.New("handler is nil")
+	}
+	handleMethod := reflect.ValueOf(handler).MethodByName("Handle")
+	if !handleMethod.IsValid() {
+		return "", nil, fmt.Errorf("handle method can not be found, handler: %s", reflect.TypeOf(handler))
+	}
+
+	// 同一类型的处理器可以通过TaskMethod区分任务方法，例如工作流
+	if named, ok := handler.(interface{ TaskMethod() string }); ok {
+		return appName + "/" + named.TaskMethod(), &handleMethod, nil
+	}
+
+	// 转换后的处理器使用原始处理器的类型名作为任务方法
+	var target any = handler
+	if wrapper, ok := handler.(interface{ Unwrap() any }); ok {
+		if target = wrapper.Unwrap(); target == nil {
+			return "", nil, errors.New("handler is nil")
+		}
+	}
+	return appName + "/" + reflect.TypeOf(target).String() + ".Handle", &handleMethod, nil
+}
+
+// validateChains 检查后续任务是否形成环，tasks为全部任务，key为任务方法，后续任务可以使用任务名称或者任务方法，不在本执行器上的后续任务不检查
+func validateChains(tasks map[string]*bean.TaskOptions) error {
+	names := make(map[string]string)
+	for method, options := range tasks {
+		names[options.Name] = method
+	}
+	resolve := func(target string) string {
+		if tasks[target] != nil {
+			return target
+		}
+		return names[target]
+	}
+
+	// 深度优先遍历，visiting为当前路径上的任务，visited为已经检查过的任务
+	visiting := make(map[string]bool)
+	visited := make(map[string]bool)
+	var path []string
+	var visit func(method string) error
+	visit = func(method string) error {
+		if visiting[method] {
+			var cycle []string
+			for i := len(path) - 1; i >= 0; i-- {
+				cycle = append([]string{tasks[path[i]].Name}, cycle...)
+				if path[i] == method {
+					break
+				}
+			}
+			return fmt.Errorf("chain cycle detected: %s -> %s", strings.Join(cycle, " -> "), tasks[method].Name)
+		}
+		if visited[method] {
+			return nil
+		}
+		visiting[method] = true
+		path = append(path, method)
+		options := tasks[method]
+		for _, target := range append(append([]string{}, options.OnSuccess...), options.OnFailure...) {
+			if next := resolve(target); next != "" {
+				if err := visit(next); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		visiting[method] = false
+		visited[method] = true
+		return nil
+	}
+
+	for _, method := range sortedKeys(tasks) {
+		if err := visit(method); err != nil {
+			return err
+		}
+	}
+	return nil
+}
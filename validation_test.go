@@ -0,0 +1,121 @@
+package cronjob
+
+import (
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/workflow"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Created in 2026-10-18 16:30.
+// @author Horace
+
+// TestAddTasksValidation 测试批量添加任务时一次性返回所有问题
+func TestAddTasksValidation(t *testing.T) {
+	client := newExecutorClient(bean.ExecutorOptions{AppName: "validation"})
+
+	err := client.AddTasks(
+		TaskDefinition{Handler: DemoTask{}, Options: bean.TaskOptions{Name: "demo", Cron: "0 0 * * * ?"}},
+		TaskDefinition{Handler: DemoTask{}, Options: bean.TaskOptions{Name: "demo", Cron: "0 0 25 * * ?"}},
+		TaskDefinition{Handler: DemoTask1{}, Options: bean.TaskOptions{ExpireTime: 10 * 60 * 1000, Timeout: 20000}},
+		TaskDefinition{Options: bean.TaskOptions{Name: "nil", Cron: "0 0 * * * ?"}},
+	)
+	if err == nil {
+		t.Fatalf("add tasks should fail")
+	}
+	for _, problem := range []string{"duplicate method", "duplicate name", "hour field value 25", "name is required", "cron is required", "expireTime", "timeout", "handler is nil"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("problem %q not reported, err: %v", problem, err)
+		}
+	}
+	if len(client.registry.Options()) != 0 {
+		t.Errorf("no task should be added when validation fails")
+	}
+
+	if err = client.TryAddTask(DemoTask{}, bean.TaskOptions{Name: "demo", Cron: "0 0 * * * ?"}); err != nil {
+		t.Fatalf("add task failed, err: %v", err)
+	}
+	if _, options := client.registry.Get("validation/cronjob.DemoTask.Handle"); options == nil || options.Timeout != 10000 {
+		t.Errorf("task options default value not applied, options: %v", options)
+	}
+	if err = client.TryAddTask(DemoTask{}, bean.TaskOptions{Name: "demo2", Cron: "0 0 * * * ?"}); err == nil {
+		t.Errorf("duplicate method should fail")
+	}
+
+	// 返回error的处理器使用原始处理器的类型名作为任务方法
+	if err = client.TryAddTask(task.HandleError(DemoErrorTask{}), bean.TaskOptions{Name: "error", Cron: "0 0 * * * ?"}); err != nil {
+		t.Fatalf("add error task failed, err: %v", err)
+	}
+	if handler, _ := client.registry.Get("validation/cronjob.DemoErrorTask.Handle"); handler == nil {
+		t.Errorf("error task not registered with the original type name, tasks: %v", client.registry.Options())
+	}
+
+	if err = ValidateExecutorOptions(&bean.ExecutorOptions{Tag: "common"}); err == nil || !strings.Contains(err.Error(), "signKey is required") {
+		t.Errorf("executor options should report all problems, err: %v", err)
+	}
+}
+
+// TestChainCycle 测试添加任务时检查后续任务形成的环
+func TestChainCycle(t *testing.T) {
+	client := newExecutorClient(bean.ExecutorOptions{AppName: "chain"})
+	err := client.AddTasks(
+		TaskDefinition{Handler: DemoTask{}, Options: bean.TaskOptions{Name: "a", Cron: "0 0 * * * ?", OnSuccess: []string{"b"}}},
+		TaskDefinition{Handler: DemoTask1{}, Options: bean.TaskOptions{Name: "b", Cron: "0 0 * * * ?", FailureStrategy: bean.FailureDiscard, OnFailure: []string{"chain/cronjob.DemoTask.Handle"}}},
+	)
+	if err == nil || !strings.Contains(err.Error(), "chain cycle detected: a -> b -> a") {
+		t.Fatalf("cycle should be detected, err: %v", err)
+	}
+
+	// 逐个添加任务时与已有的任务一起检查
+	if err = client.TryAddTask(DemoTask{}, bean.TaskOptions{Name: "a", Cron: "0 0 * * * ?", OnSuccess: []string{"b", "remote"}}); err != nil {
+		t.Fatalf("add task failed, err: %v", err)
+	}
+	if err = client.TryAddTask(DemoTask1{}, bean.TaskOptions{Name: "b", Cron: "0 0 * * * ?", OnSuccess: []string{"c"}}); err != nil {
+		t.Fatalf("add task failed, err: %v", err)
+	}
+	if err = client.TryAddTask(task.HandleError(DemoErrorTask{}), bean.TaskOptions{Name: "c", Cron: "0 0 * * * ?", FailureStrategy: bean.FailureDiscard, OnFailure: []string{"a"}}); err == nil || !strings.Contains(err.Error(), "c -> a -> b -> c") {
+		t.Errorf("cycle with existing tasks should be detected, err: %v", err)
+	}
+	if err = client.UpdateTask(DemoTask1{}, bean.TaskOptions{Name: "b", Cron: "0 0 * * * ?", OnSuccess: []string{"b"}}); err == nil {
+		t.Errorf("self cycle should be detected")
+	}
+
+	// 调度器不下发重试次数，失败重试的任务不能配置OnFailure，单机模式由执行器自己计数
+	if err = client.TryAddTask(task.HandleError(DemoErrorTask{}), bean.TaskOptions{Name: "d", Cron: "0 0 * * * ?", OnFailure: []string{"a"}}); err == nil || !strings.Contains(err.Error(), "onFailure requires failureStrategy discard") {
+		t.Errorf("onFailure with failure retry should be rejected, err: %v", err)
+	}
+	standalone := newExecutorClient(bean.ExecutorOptions{AppName: "chain", Standalone: true})
+	if err = standalone.TryAddTask(task.HandleError(DemoErrorTask{}), bean.TaskOptions{Name: "d", Cron: "0 0 * * * ?", OnFailure: []string{"remote"}}); err != nil {
+		t.Errorf("onFailure with failure retry should be allowed in standalone mode, err: %v", err)
+	}
+}
+
+// TestAddWorkflow 测试添加工作流时校验节点引用的任务，不同的工作流注册为不同的任务方法
+func TestAddWorkflow(t *testing.T) {
+	client := newExecutorClient(bean.ExecutorOptions{AppName: "workflow"})
+	definition := &workflow.Workflow{Name: "daily", Nodes: []workflow.Node{
+		{Name: "first", Task: "demo"},
+		{Name: "second", Handler: DemoTask1{}, DependsOn: []string{"first"}},
+	}}
+	if err := client.AddWorkflow(definition, bean.TaskOptions{Cron: "0 0 * * * ?"}); err == nil || !strings.Contains(err.Error(), "task demo not found") {
+		t.Fatalf("unregistered node task should be reported, err: %v", err)
+	}
+
+	client.AddTask(DemoTask{}, bean.TaskOptions{Name: "demo", Cron: "0 0 * * * ?"})
+	if err := client.AddWorkflow(definition, bean.TaskOptions{Cron: "0 0 * * * ?"}); err != nil {
+		t.Fatalf("add workflow failed, err: %v", err)
+	}
+	if err := client.AddWorkflow(&workflow.Workflow{Name: "weekly", Nodes: definition.Nodes}, bean.TaskOptions{Cron: "0 0 * * * ?"}); err != nil {
+		t.Fatalf("add another workflow failed, err: %v", err)
+	}
+	handleMethod, options := client.registry.Get("workflow/workflow.daily.Handle")
+	if handleMethod == nil || options.Name != "daily" {
+		t.Fatalf("workflow should be registered by name, options: %v", options)
+	}
+	result := handleMethod.Call([]reflect.Value{reflect.ValueOf(&task.TaskParams{})})[0].Interface().(*task.HandlerResult)
+	if !result.IsSuccess() || result.Counters["success"] != 2 {
+		t.Errorf("workflow should run registered and inline nodes, result: %+v", result)
+	}
+}
@@ -0,0 +1,204 @@
+package webserver
+
+// Created in 2026-10-18 13:20.
+// @author Horace
+
+import (
+	logger "github.com/cihub/seelog"
+	"github.com/gin-gonic/gin"
+	"github.com/horacedh/cronjob-executor/bean"
+	cronjobContext "github.com/horacedh/cronjob-executor/context"
+	"github.com/horacedh/cronjob-executor/services"
+	"github.com/horacedh/cronjob-executor/utils"
+	"github.com/horacedh/cronjob-executor/webresult"
+	"sort"
+	"sync"
+)
+
+// 单例模式
+var (
+	adminController     AdminController
+	adminControllerOnce sync.Once
+)
+
+// AdminController 管理接口，用于排查执行器内部状态
+type AdminController interface {
+	// Tasks 已注册的任务处理器及任务配置
+	Tasks() gin.HandlerFunc
+	// Queue 队列中等待执行的任务
+	Queue() gin.HandlerFunc
+	// Running 正在执行的任务
+	Running() gin.HandlerFunc
+	// Results 等待发送给调度器的任务结果
+	Results() gin.HandlerFunc
+	// Status 注册和心跳状态
+	Status() gin.HandlerFunc
+	// Run 在本执行器上立即执行任务
+	Run() gin.HandlerFunc
+	// Drain 开始摘流
+	Drain() gin.HandlerFunc
+	// Resume 结束摘流
+	Resume() gin.HandlerFunc
+}
+
+// adminControllerImpl 实现类
+type adminControllerImpl struct {
+}
+
+// taskInfo 任务信息
+type taskInfo struct {
+	// Method 任务方法，包路径+方法名
+	Method string `json:"method"`
+	// Options 任务配置
+	Options *bean.TaskOptions `json:"options"`
+}
+
+// runRequest 立即执行任务的请求参数
+type runRequest struct {
+	bean.RunNowOptions
+	// Task 任务方法（包路径+方法名）或者任务名称
+	Task string `json:"task"`
+}
+
+// executorStatus 执行器状态
+type executorStatus struct {
+	// Address 执行器地址
+	Address string `json:"address"`
+	// Version 执行器SDK版本
+	Version string `json:"version"`
+	// Shutdown 是否已经停机
+	Shutdown bool `json:"shutdown"`
+	// Draining 是否正在摘流
+	Draining bool `json:"draining"`
+	// Drained 是否摘流完成
+	Drained bool `json:"drained"`
+	// Register 注册状态
+	Register bean.RegisterStatus `json:"register"`
+	// Heartbeat 心跳状态
+	Heartbeat bean.HeartbeatStatus `json:"heartbeat"`
+}
+
+// drainStatus 摘流状态
+type drainStatus struct {
+	// Draining 是否正在摘流
+	Draining bool `json:"draining"`
+	// Drained 是否摘流完成
+	Drained bool `json:"drained"`
+}
+
+// Tasks 已注册的任务处理器及任务配置
+func (controller *adminControllerImpl) Tasks() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		tasks := make([]taskInfo, 0)
+		if dispatcherService := services.GetDispatcherService(); dispatcherService != nil {
+			for method, options := range dispatcherService.GetTaskOptions() {
+				tasks = append(tasks, taskInfo{Method: method, Options: options})
+			}
+		}
+		sort.Slice(tasks, func(i, j int) bool {
+			return tasks[i].Method < tasks[j].Method
+		})
+		utils.RenderMsgObject(context, webresult.Success(tasks))
+	}
+}
+
+// Queue 队列中等待执行的任务
+func (controller *adminControllerImpl) Queue() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		queuedTasks := make([]*services.QueuedTask, 0)
+		if dispatcherService := services.GetDispatcherService(); dispatcherService != nil {
+			queuedTasks = dispatcherService.GetQueuedTasks()
+		}
+		utils.RenderMsgObject(context, webresult.Success(queuedTasks))
+	}
+}
+
+// Running 正在执行的任务
+func (controller *adminControllerImpl) Running() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		runningTasks := make([]*services.RunningTask, 0)
+		if dispatcherService := services.GetDispatcherService(); dispatcherService != nil {
+			runningTasks = dispatcherService.GetRunningTasks()
+		}
+		utils.RenderMsgObject(context, webresult.Success(runningTasks))
+	}
+}
+
+// Results 等待发送给调度器的任务结果
+func (controller *adminControllerImpl) Results() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		utils.RenderMsgObject(context, webresult.Success(services.GetResultSendService().GetPendingResults()))
+	}
+}
+
+// Status 注册和心跳状态
+func (controller *adminControllerImpl) Status() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		utils.RenderMsgObject(context, webresult.Success(executorStatus{
+			Address:   GetHttpServer().GetAddress(),
+			Version:   cronjobContext.Version,
+			Shutdown:  cronjobContext.Shutdown.Load(),
+			Draining:  services.GetDrainService().IsDraining(),
+			Drained:   services.GetDrainService().IsDrained(),
+			Register:  services.GetRegisterService().GetStatus(),
+			Heartbeat: services.GetHeartbeatService().GetStatus(),
+		}))
+	}
+}
+
+// Run 在本执行器上立即执行任务，同步返回任务处理结果
+func (controller *adminControllerImpl) Run() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var request runRequest
+		if err := context.ShouldBindJSON(&request); err != nil || request.Task == "" {
+			logger.Warnf("received admin run request, invalid params, err: %v", err)
+			utils.RenderMsgObject(context, webresult.ERROR_PARAMS)
+			return
+		}
+
+		// 已经停机或者正在摘流时与任务分发接口一样拒绝执行
+		dispatcherService := services.GetDispatcherService()
+		if dispatcherService == nil || cronjobContext.Shutdown.Load() || cronjobContext.Draining.Load() {
+			logger.Warnf("received admin run request, executor is not running or draining, task: %s", request.Task)
+			utils.RenderMsgObject(context, webresult.ERROR_EXECUTE_SHUTDOWN)
+			return
+		}
+
+		handlerResult, err := dispatcherService.RunNow(GetHttpServer().GetAddress(), request.Task, request.RunNowOptions)
+		if err != nil {
+			utils.RenderMsgObject(context, webresult.MsgObject{Code: webresult.ERROR_PARAMS.Code, Msg: err.Error()})
+			return
+		}
+		utils.RenderMsgObject(context, webresult.Success(handlerResult))
+	}
+}
+
+// Drain 开始摘流，从调度器注销并拒绝新的调度请求，返回摘流状态
+func (controller *adminControllerImpl) Drain() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		drainService := services.GetDrainService()
+		if !drainService.Drain(GetHttpServer().GetAddress()) {
+			logger.Infof("received admin drain request, executor is already draining")
+		}
+		utils.RenderMsgObject(context, webresult.Success(drainStatus{Draining: drainService.IsDraining(), Drained: drainService.IsDrained()}))
+	}
+}
+
+// Resume 结束摘流，重新注册执行器和全部任务
+func (controller *adminControllerImpl) Resume() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		drainService := services.GetDrainService()
+		if !drainService.Resume() {
+			logger.Infof("received admin resume request, executor is not draining")
+		}
+		utils.RenderMsgObject(context, webresult.Success(drainStatus{Draining: drainService.IsDraining(), Drained: drainService.IsDrained()}))
+	}
+}
+
+// GetAdminController 获取实例对象
+func GetAdminController() AdminController {
+	adminControllerOnce.Do(func() {
+		adminController = &adminControllerImpl{}
+	})
+	return adminController
+}
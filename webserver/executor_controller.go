@@ -4,14 +4,18 @@ package webserver
 // @author Horace
 
 import (
+	stdContext "context"
 	"encoding/json"
 	logger "github.com/cihub/seelog"
 	"github.com/gin-gonic/gin"
 	cronjobContext "github.com/horacedh/cronjob-executor/context"
 	"github.com/horacedh/cronjob-executor/services"
 	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/tracing"
 	"github.com/horacedh/cronjob-executor/utils"
 	"github.com/horacedh/cronjob-executor/webresult"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"io"
 	"sync"
 	"time"
@@ -36,6 +40,11 @@ type ExecutorControllerImpl struct {
 // Dispatcher 任务分发接口
 func (controller ExecutorControllerImpl) Dispatcher() gin.HandlerFunc {
 	return func(context *gin.Context) {
+		// 提取调度器传递的链路信息，不使用请求的上下文，避免请求结束后任务上下文被取消
+		traceContext := tracing.Extract(stdContext.Background(), context.Request.Header)
+		traceContext, span := tracing.Tracer().Start(traceContext, tracing.SpanDispatch, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
 		bytes, err := io.ReadAll(context.Request.Body)
 		if err != nil {
 			logger.Errorf("received execute request, read request body failed, err: %v", err)
@@ -50,9 +59,9 @@ func (controller ExecutorControllerImpl) Dispatcher() gin.HandlerFunc {
 			return
 		}
 
-		// 如果已经停机
-		if cronjobContext.Shutdown.Load() {
-			logger.Warnf("received execute request, executor is not running, ignore the task, params:%s", body)
+		// 如果已经停机或者正在摘流
+		if cronjobContext.Shutdown.Load() || cronjobContext.Draining.Load() {
+			logger.Warnf("received execute request, executor is not running or draining, ignore the task, params:%s", body)
 			utils.RenderMsgObject(context, webresult.ERROR_EXECUTE_SHUTDOWN)
 			return
 		}
@@ -62,18 +71,22 @@ func (controller ExecutorControllerImpl) Dispatcher() gin.HandlerFunc {
 		err = json.Unmarshal(bytes, &taskParams)
 		if err != nil {
 			logger.Errorf("received execute request, json unmarshal failed, params:%s, err: %v", body, err)
+			span.SetStatus(codes.Error, "json unmarshal failed")
 			utils.RenderMsgObject(context, webresult.ERROR)
 			return
 		}
 
+		span.SetAttributes(tracing.TaskAttributes(taskParams.TaskLogId, taskParams.TaskId, taskParams.Method)...)
+		taskParams.SetContext(traceContext)
 		taskParams.ReceivedDispatcherTime = time.Now().UnixMilli()
-		var queueSize = services.GetDispatcherService().AddTask(&taskParams)
-		logger.Debugf("received execute request, queueSize:%d, params:%v", queueSize, utils.ToJsonString(taskParams))
+		// 调度器超时重试时，重复的任务日志ID直接确认，不会重复执行
+		var queueSize, duplicate = services.GetDispatcherService().Dispatch(&taskParams)
+		logger.Debugf("received execute request, queueSize:%d, duplicate:%t, params:%v", queueSize, duplicate, utils.ToJsonString(taskParams))
 		utils.RenderMsgObject(context, webresult.SUCCESS)
 	}
 }
 
-// verifySign 校验签名
+// verifySign 校验任务分发接口的签名
 func (controller ExecutorControllerImpl) verifySign(context *gin.Context, body string) bool {
 	var signKey = cronjobContext.SignKey.Load()
 	var sign = context.GetHeader("sign")
@@ -83,7 +96,7 @@ func (controller ExecutorControllerImpl) verifySign(context *gin.Context, body s
 	serverSign := utils.Sign(signKey.(string), token, times, body, params)
 	var success = serverSign == sign
 	if !success {
-		logger.Errorf("received execute request, sign verify failed, signKey:%s, serverSign:%s, sign:%s, token:%s, times:%s, params:%s", signKey, serverSign, sign, token, times, body)
+		logger.Errorf("received request, sign verify failed, url:%s, signKey:%s, serverSign:%s, sign:%s, token:%s, times:%s, params:%s", context.Request.RequestURI, signKey, serverSign, sign, token, times, body)
 	}
 	return success
 }
@@ -1,12 +1,17 @@
 package webserver
 
 import (
+	"bytes"
 	logger "github.com/cihub/seelog"
 	"github.com/gin-gonic/gin"
+	cronjobContext "github.com/horacedh/cronjob-executor/context"
 	"github.com/horacedh/cronjob-executor/utils"
 	"github.com/horacedh/cronjob-executor/webresult"
+	"io"
+	"net"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"time"
 )
 
@@ -57,3 +62,66 @@ func globalErrorHandler() gin.HandlerFunc {
 		context.Next()
 	}
 }
+
+// adminSignExpiration 管理接口签名的有效期，请求头times与当前时间相差超过此时间的请求会被拒绝，防止重放
+const adminSignExpiration = 5 * time.Minute
+
+// adminInterceptor 管理接口拦截器，只允许本机访问，或者携带合法签名的请求访问，write为true时只接受SignKey的签名
+func adminInterceptor(write bool) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		request := context.Request
+
+		// 只根据连接的远程地址判断，不信任X-Forwarded-For等请求头
+		host, _, err := net.SplitHostPort(request.RemoteAddr)
+		if err == nil {
+			if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+				context.Next()
+				return
+			}
+		}
+
+		// 非本机访问时校验签名，签名在任务分发接口的基础上加入请求方法和路径，避免其他接口的签名被重放到管理接口
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			logger.Errorf("received admin request, read request body failed, url: %s, err: %v", request.RequestURI, err)
+			utils.RenderMsgObject(context, webresult.ERROR)
+			context.Abort()
+			return
+		}
+		request.Body = io.NopCloser(bytes.NewReader(body))
+
+		signKey, _ := cronjobContext.SignKey.Load().(string)
+		keys := []string{signKey}
+		if readKey, _ := cronjobContext.AdminReadKey.Load().(string); !write && readKey != "" {
+			keys = append(keys, readKey)
+		}
+		if !verifyAdminSign(context, string(body), keys) {
+			logger.Warnf("received admin request, sign verify failed, method: %s, url: %s, clientIp: %s", request.Method, request.RequestURI, request.RemoteAddr)
+			utils.RenderMsgObject(context, webresult.ERROR_SIGN)
+			context.Abort()
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// verifyAdminSign 校验管理接口的签名，times必须在有效期内，签名由keys中任意一个Key生成即可
+func verifyAdminSign(context *gin.Context, body string, keys []string) bool {
+	sign := context.GetHeader("sign")
+	times, err := strconv.ParseInt(context.GetHeader("times"), 10, 64)
+	if sign == "" || err != nil {
+		return false
+	}
+	if elapsed := time.Since(time.UnixMilli(times)); elapsed > adminSignExpiration || elapsed < -adminSignExpiration {
+		logger.Warnf("received admin request, sign expired, url: %s, times: %d", context.Request.RequestURI, times)
+		return false
+	}
+	params := map[string]interface{}{"method": context.Request.Method, "path": context.Request.URL.Path}
+	for _, key := range keys {
+		if key != "" && utils.Sign(key, context.GetHeader("token"), context.GetHeader("times"), body, params) == sign {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package webserver
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	cronjobContext "github.com/horacedh/cronjob-executor/context"
+	"github.com/horacedh/cronjob-executor/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-19 09:40.
+// @author Horace
+
+// TestAdminInterceptor 测试非本机访问管理接口时，签名包含请求方法和路径，过期的签名被拒绝，只读Key只能访问查询类接口
+func TestAdminInterceptor(t *testing.T) {
+	cronjobContext.SignKey.Store("sign-key")
+	cronjobContext.AdminReadKey.Store("read-key")
+	defer cronjobContext.AdminReadKey.Store("")
+
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	ok := func(context *gin.Context) { context.String(http.StatusOK, "ok") }
+	engine.GET("/admin/status", adminInterceptor(false), ok)
+	engine.POST("/admin/drain", adminInterceptor(true), ok)
+	request := func(method, path, key string, signPath string, times time.Time) bool {
+		request := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		millis := fmt.Sprintf("%d", times.UnixMilli())
+		request.Header.Set("times", millis)
+		request.Header.Set("token", "admin")
+		request.Header.Set("sign", utils.Sign(key, "admin", millis, "{}", map[string]interface{}{"method": method, "path": signPath}))
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, request)
+		return recorder.Body.String() == "ok"
+	}
+
+	now := time.Now()
+	if !request(http.MethodGet, "/admin/status", "sign-key", "/admin/status", now) || !request(http.MethodPost, "/admin/drain", "sign-key", "/admin/drain", now) {
+		t.Errorf("request signed with the sign key should be accepted")
+	}
+	if !request(http.MethodGet, "/admin/status", "read-key", "/admin/status", now) {
+		t.Errorf("read route should accept the read key")
+	}
+	if request(http.MethodPost, "/admin/drain", "read-key", "/admin/drain", now) {
+		t.Errorf("write route should reject the read key")
+	}
+	if request(http.MethodPost, "/admin/drain", "sign-key", "/admin/status", now) {
+		t.Errorf("sign of another path should be rejected")
+	}
+	if request(http.MethodGet, "/admin/status", "sign-key", "/admin/status", now.Add(-adminSignExpiration-time.Minute)) {
+		t.Errorf("expired sign should be rejected")
+	}
+}
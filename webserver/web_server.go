@@ -4,11 +4,15 @@ package webserver
 // @author Horace
 
 import (
+	stdContext "context"
+	"errors"
 	"fmt"
 	logger "github.com/cihub/seelog"
 	"github.com/gin-gonic/gin"
+	"github.com/horacedh/cronjob-executor/metrics"
 	"github.com/horacedh/cronjob-executor/utils"
 	"net"
+	"net/http"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -28,6 +32,8 @@ type WebServer interface {
 	IsStarted() bool
 	// GetAddress 获取地址，ip:host 格式
 	GetAddress() string
+	// Shutdown 优雅关闭服务器，等待正在处理的请求完成，直到ctx超时
+	Shutdown(ctx stdContext.Context) error
 }
 
 // webServerImpl 实现类
@@ -36,6 +42,17 @@ type webServerImpl struct {
 	Port int32
 	// 启动状态
 	started atomic.Bool
+	// server Http服务器
+	server atomic.Pointer[http.Server]
+}
+
+// Shutdown 优雅关闭服务器
+func (webServer *webServerImpl) Shutdown(ctx stdContext.Context) error {
+	server := webServer.server.Load()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
 }
 
 // GetAddress 获取地址，ip:host 格式
@@ -79,9 +96,13 @@ func (webServer *webServerImpl) Start() {
 	}
 
 	logger.Infof("start cron-job web server, at Port: %d", webServer.Port)
+	server := &http.Server{Handler: engine.Handler()}
+	webServer.server.Store(server)
 	webServer.started.Store(true)
-	err := engine.RunListener(listen)
-	if err != nil {
+	err := server.Serve(listen)
+	if errors.Is(err, http.ErrServerClosed) {
+		logger.Infof("cron-job web server stopped, Port: %d", webServer.Port)
+	} else if err != nil {
 		logger.Errorf("start cron-job web server error, Port: %d, error: %s", webServer.Port, err.Error())
 	}
 	logger.Flush()
@@ -92,6 +113,22 @@ func initRouter(engine *gin.Engine) {
 	// 任务分发接口
 	executorController := GetExecutorController()
 	engine.POST("/dispatch", executorController.Dispatcher())
+
+	// Prometheus指标接口
+	engine.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// 管理接口，只允许本机或者携带签名访问，查询类接口可以使用只读签名Key，修改状态的接口只能使用SignKey
+	adminController := GetAdminController()
+	adminRead := engine.Group("/admin", adminInterceptor(false))
+	adminRead.GET("/tasks", adminController.Tasks())
+	adminRead.GET("/queue", adminController.Queue())
+	adminRead.GET("/running", adminController.Running())
+	adminRead.GET("/results", adminController.Results())
+	adminRead.GET("/status", adminController.Status())
+	adminWrite := engine.Group("/admin", adminInterceptor(true))
+	adminWrite.POST("/run", adminController.Run())
+	adminWrite.POST("/drain", adminController.Drain())
+	adminWrite.POST("/resume", adminController.Resume())
 }
 
 // GetHttpServer 获取实例对象
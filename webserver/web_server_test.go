@@ -0,0 +1,79 @@
+package webserver
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/horacedh/cronjob-executor/bean"
+	cronjobContext "github.com/horacedh/cronjob-executor/context"
+	"github.com/horacedh/cronjob-executor/services"
+	"github.com/horacedh/cronjob-executor/task"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Created in 2026-10-19 02:30.
+// @author Horace
+
+// TestMetricsRoute 测试执行任务后通过/metrics接口获取任务执行结果和耗时指标
+func TestMetricsRoute(t *testing.T) {
+	registry := services.NewTaskRegistry()
+	handler := reflect.ValueOf(func(params *task.TaskParams) *task.HandlerResult { return task.Success() })
+	registry.Put("app/metrics.Handle", &handler, &bean.TaskOptions{Name: "metrics"})
+	dispatcher := services.InitDispatcherService(bean.ExecutorOptions{DedupTTL: bean.DefaultDedupTTL}, registry, nil)
+	if result, err := dispatcher.RunNow("127.0.0.1:8527", "metrics", bean.RunNowOptions{}); err != nil || !result.IsSuccess() {
+		t.Fatalf("run task failed, result: %+v, err: %v", result, err)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	initRouter(engine)
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", recorder.Code)
+	}
+	body := recorder.Body.String()
+	for _, metric := range []string{
+		`cronjob_executor_task_results_total{method="app/metrics.Handle",state="success"} 1`,
+		`cronjob_executor_handler_duration_seconds_count{method="app/metrics.Handle"} 1`,
+		`cronjob_executor_dispatcher_queue_size`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, metric) {
+			t.Errorf("metric %q not exported", metric)
+		}
+	}
+}
+
+// TestAdminRun 测试摘流或者停机时管理接口拒绝立即执行任务
+func TestAdminRun(t *testing.T) {
+	services.InitDispatcherService(bean.ExecutorOptions{DedupTTL: bean.DefaultDedupTTL}, services.NewTaskRegistry(), nil)
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	initRouter(engine)
+	run := func() string {
+		request := httptest.NewRequest(http.MethodPost, "/admin/run", strings.NewReader(`{"task":"missing"}`))
+		request.RemoteAddr = "127.0.0.1:52000"
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, request)
+		return recorder.Body.String()
+	}
+
+	cronjobContext.Draining.Store(true)
+	body := run()
+	cronjobContext.Draining.Store(false)
+	if !strings.Contains(body, `"code":15`) {
+		t.Errorf("run should be rejected while draining, body: %s", body)
+	}
+	cronjobContext.Shutdown.Store(true)
+	body = run()
+	cronjobContext.Shutdown.Store(false)
+	if !strings.Contains(body, `"code":15`) {
+		t.Errorf("run should be rejected after shutdown, body: %s", body)
+	}
+	if body = run(); !strings.Contains(body, "task not found") {
+		t.Errorf("run should reach the dispatcher when running, body: %s", body)
+	}
+}
@@ -0,0 +1,45 @@
+package cronjob
+
+// Created in 2026-10-18 23:50.
+// @author Horace
+
+import (
+	"errors"
+	"github.com/horacedh/cronjob-executor/bean"
+	"github.com/horacedh/cronjob-executor/task"
+	"github.com/horacedh/cronjob-executor/workflow"
+	"reflect"
+)
+
+// AddWorkflow 添加工作流，任务名称为空时使用工作流名称，工作流不合法时不会添加任务
+func (client *executorClientImpl) AddWorkflow(definition *workflow.Workflow, options bean.TaskOptions) error {
+	if definition == nil {
+		return errors.New("workflow is nil")
+	}
+	if err := definition.Validate(client.resolveNode); err != nil {
+		return err
+	}
+	if options.Name == "" {
+		options.Name = definition.Name
+	}
+	return client.TryAddTask(workflow.NewHandler(definition, client.resolveNode), options)
+}
+
+// resolveNode 根据任务名称或者任务方法查找已注册任务的处理方法
+func (client *executorClientImpl) resolveNode(name string) (workflow.NodeFunc, bool) {
+	method, _ := client.registry.Find(name)
+	if method == "" {
+		return nil, false
+	}
+	handleMethod, _ := client.registry.Get(method)
+	if handleMethod == nil {
+		return nil, false
+	}
+	return func(params *task.TaskParams) *task.HandlerResult {
+		results := handleMethod.Call([]reflect.Value{reflect.ValueOf(params)})
+		if len(results) == 0 || results[0].IsNil() {
+			return nil
+		}
+		return results[0].Interface().(*task.HandlerResult)
+	}, true
+}
@@ -0,0 +1,437 @@
+package workflow
+
+// Created in 2026-10-18 23:30.
+// @author Horace
+
+import (
+	stdContext "context"
+	"errors"
+	"fmt"
+	"github.com/horacedh/cronjob-executor/task"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NodeFunc 节点处理方法
+type NodeFunc func(params *task.TaskParams) *task.HandlerResult
+
+// Resolver 根据任务名称或者任务方法查找已注册任务的处理方法
+type Resolver func(name string) (NodeFunc, bool)
+
+// Node 工作流节点
+type Node struct {
+	// Name 节点名称，在工作流中唯一
+	Name string
+	// Task 已注册任务的名称或者任务方法，与Handler二选一
+	Task string
+	// Handler 节点处理器，不需要注册为任务，与Task二选一
+	Handler task.TaskHandler
+	// DependsOn 依赖的节点名称，依赖的节点全部成功后才执行，没有依赖的节点并行执行
+	DependsOn []string
+	// Params 节点参数，为空时使用工作流任务的参数
+	Params string
+	// Retries 节点失败后的重试次数，默认0不重试
+	Retries int
+	// RetryInterval 节点重试的间隔时间，毫秒
+	RetryInterval int
+	// Timeout 节点每次执行的超时时间，毫秒，默认0不单独限制，受工作流的超时时间限制
+	Timeout int
+}
+
+// Workflow 工作流，由已注册任务的处理方法组成的有向无环图，作为一个任务注册到调度器
+type Workflow struct {
+	// Name 工作流名称，用于生成任务方法
+	Name string
+	// Nodes 工作流节点
+	Nodes []Node
+	// MaxParallel 最多同时执行的节点数量，默认0不限制
+	MaxParallel int
+	// ExecutionTimeout 整个工作流的执行超时时间，默认0使用任务超时时间，最大10秒。
+	// 大于0时工作流最多执行此时间，超过任务超时时间后调度器可能认为执行失败并按照失败策略重试，需要同时调整调度器上的任务配置
+	ExecutionTimeout time.Duration
+}
+
+// NodeState 节点执行状态
+type NodeState string
+
+const (
+	// NodeSuccess 执行成功
+	NodeSuccess NodeState = "success"
+	// NodeFailed 重试后仍然失败或者超时
+	NodeFailed NodeState = "failed"
+	// NodeSkipped 依赖的节点没有成功或者工作流已经取消，没有执行
+	NodeSkipped NodeState = "skipped"
+)
+
+// NodeResult 节点执行结果
+type NodeResult struct {
+	// Name 节点名称
+	Name string `json:"name"`
+	// State 执行状态
+	State NodeState `json:"state"`
+	// Msg 处理结果描述信息，失败时为失败原因
+	Msg string `json:"msg,omitempty"`
+	// Attempts 执行次数
+	Attempts int `json:"attempts"`
+	// StartTime 第一次开始执行的时间，毫秒
+	StartTime int64 `json:"startTime,omitempty"`
+	// ElapsedTime 耗时，毫秒，包含重试
+	ElapsedTime int `json:"elapsedTime"`
+	// Output 节点的输出数据
+	Output any `json:"output,omitempty"`
+}
+
+// Result 工作流的执行结果，作为工作流任务的输出数据发送给调度器
+type Result struct {
+	// Nodes 节点执行结果，按照定义的顺序排序
+	Nodes []*NodeResult `json:"nodes"`
+}
+
+// Validate 校验工作流，节点名称唯一，依赖的节点存在，没有环，每个节点可以找到处理方法，一次性返回所有问题
+func (workflow *Workflow) Validate(resolve Resolver) error {
+	var errs []error
+	if workflow.Name == "" {
+		errs = append(errs, errors.New("workflow name is required"))
+	}
+	if len(workflow.Nodes) == 0 {
+		errs = append(errs, errors.New("workflow has no nodes"))
+	}
+
+	nodes := make(map[string]*Node, len(workflow.Nodes))
+	for i := range workflow.Nodes {
+		node := &workflow.Nodes[i]
+		switch {
+		case node.Name == "":
+			errs = append(errs, fmt.Errorf("nodes[%d]: name is required", i))
+		case nodes[node.Name] != nil:
+			errs = append(errs, fmt.Errorf("node %q: duplicate name", node.Name))
+		}
+		nodes[node.Name] = node
+
+		switch {
+		case node.Handler == nil && node.Task == "":
+			errs = append(errs, fmt.Errorf("node %q: task or handler is required", node.Name))
+		case node.Handler != nil && node.Task != "":
+			errs = append(errs, fmt.Errorf("node %q: only one of task and handler can be set", node.Name))
+		case node.Task != "":
+			if _, ok := resolve(node.Task); !ok {
+				errs = append(errs, fmt.Errorf("node %q: task %s not found", node.Name, node.Task))
+			}
+		}
+		if node.Retries < 0 || node.RetryInterval < 0 || node.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("node %q: retries, retryInterval and timeout must not be negative", node.Name))
+		}
+	}
+	for _, node := range workflow.Nodes {
+		for _, dependency := range node.DependsOn {
+			if nodes[dependency] == nil {
+				errs = append(errs, fmt.Errorf("node %q: dependency %q not found", node.Name, dependency))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		if _, err := workflow.sort(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid workflow %q: %w", workflow.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// sort 拓扑排序，存在环时返回错误
+func (workflow *Workflow) sort() ([]string, error) {
+	indegree := make(map[string]int, len(workflow.Nodes))
+	dependents := make(map[string][]string)
+	for _, node := range workflow.Nodes {
+		indegree[node.Name] += len(node.DependsOn)
+		for _, dependency := range node.DependsOn {
+			dependents[dependency] = append(dependents[dependency], node.Name)
+		}
+	}
+
+	var ready, sorted []string
+	for _, node := range workflow.Nodes {
+		if indegree[node.Name] == 0 {
+			ready = append(ready, node.Name)
+		}
+	}
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, name)
+		for _, dependent := range dependents[name] {
+			if indegree[dependent]--; indegree[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	if len(sorted) < len(workflow.Nodes) {
+		var cyclic []string
+		for name, degree := range indegree {
+			if degree > 0 {
+				cyclic = append(cyclic, name)
+			}
+		}
+		sort.Strings(cyclic)
+		return nil, fmt.Errorf("dependency cycle detected among nodes: %s", strings.Join(cyclic, ", "))
+	}
+	return sorted, nil
+}
+
+// NewHandler 创建工作流的任务处理器，已注册任务的节点在每次执行时查找处理方法，任务被修改后下一次执行生效
+func NewHandler(workflow *Workflow, resolve Resolver) task.TaskHandler {
+	return &handler{workflow: workflow, resolve: resolve}
+}
+
+// handler 工作流的任务处理器
+type handler struct {
+	// workflow 工作流
+	workflow *Workflow
+	// resolve 查找已注册任务的处理方法
+	resolve Resolver
+}
+
+// TaskMethod 任务方法，不同的工作流使用同一个处理器类型，按照工作流名称区分
+func (handler *handler) TaskMethod() string {
+	return "workflow." + handler.workflow.Name + ".Handle"
+}
+
+// Handle 按照依赖顺序执行全部节点，有节点失败时工作流失败，依赖失败节点的节点不再执行
+func (handler *handler) Handle(params *task.TaskParams) *task.HandlerResult {
+	result := handler.run(params)
+
+	counters := make(map[NodeState]int64)
+	var failed, skipped []string
+	for _, node := range result.Nodes {
+		counters[node.State]++
+		switch node.State {
+		case NodeFailed:
+			failed = append(failed, node.Name)
+		case NodeSkipped:
+			skipped = append(skipped, node.Name)
+		}
+	}
+
+	handlerResult := task.Success()
+	if len(failed)+len(skipped) > 0 {
+		handlerResult = task.Failed(fmt.Sprintf("workflow %s failed, failed nodes:%v, skipped nodes:%v", handler.workflow.Name, failed, skipped))
+	}
+	handlerResult.WithOutput(result)
+	for _, state := range []NodeState{NodeSuccess, NodeFailed, NodeSkipped} {
+		handlerResult.WithCounter(string(state), counters[state])
+	}
+	return handlerResult
+}
+
+// run 执行工作流，节点的依赖全部完成后开始执行，结果由当前goroutine统一收集
+func (handler *handler) run(params *task.TaskParams) *Result {
+	workflow := handler.workflow
+	ctx, cancel := executionContext(params.Context(), workflow.ExecutionTimeout)
+	defer cancel()
+	indegree := make(map[string]int, len(workflow.Nodes))
+	dependents := make(map[string][]string)
+	nodes := make(map[string]*Node, len(workflow.Nodes))
+	for i := range workflow.Nodes {
+		node := &workflow.Nodes[i]
+		nodes[node.Name] = node
+		indegree[node.Name] = len(node.DependsOn)
+		for _, dependency := range node.DependsOn {
+			dependents[dependency] = append(dependents[dependency], node.Name)
+		}
+	}
+
+	var semaphore chan struct{}
+	if workflow.MaxParallel > 0 {
+		semaphore = make(chan struct{}, workflow.MaxParallel)
+	}
+	results := make(map[string]*NodeResult, len(workflow.Nodes))
+	finished := make(chan *NodeResult)
+	var wg sync.WaitGroup
+	pending := len(workflow.Nodes)
+
+	// schedule 依赖全部完成后执行节点，依赖没有全部成功或者工作流已经取消时跳过，跳过的节点立即完成
+	var complete func(result *NodeResult)
+	schedule := func(node *Node) {
+		for _, dependency := range node.DependsOn {
+			if results[dependency].State != NodeSuccess {
+				complete(&NodeResult{Name: node.Name, State: NodeSkipped, Msg: "dependency " + dependency + " did not succeed"})
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			complete(&NodeResult{Name: node.Name, State: NodeSkipped, Msg: "workflow canceled: " + stdContext.Cause(ctx).Error()})
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if semaphore != nil {
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+			}
+			finished <- handler.runNode(ctx, node, params)
+		}()
+	}
+	complete = func(result *NodeResult) {
+		results[result.Name] = result
+		pending--
+		for _, dependent := range dependents[result.Name] {
+			if indegree[dependent]--; indegree[dependent] == 0 {
+				schedule(nodes[dependent])
+			}
+		}
+	}
+
+	for i := range workflow.Nodes {
+		if len(workflow.Nodes[i].DependsOn) == 0 {
+			schedule(&workflow.Nodes[i])
+		}
+	}
+	for pending > 0 {
+		complete(<-finished)
+	}
+	wg.Wait()
+
+	result := &Result{Nodes: make([]*NodeResult, 0, len(workflow.Nodes))}
+	for _, node := range workflow.Nodes {
+		result.Nodes = append(result.Nodes, results[node.Name])
+	}
+	return result
+}
+
+// runNode 执行节点，失败后按照节点的重试次数重试，错误分类为不可重试时不再重试
+func (handler *handler) runNode(ctx stdContext.Context, node *Node, params *task.TaskParams) *NodeResult {
+	if ctx.Err() != nil {
+		return &NodeResult{Name: node.Name, State: NodeSkipped, Msg: "workflow canceled: " + stdContext.Cause(ctx).Error()}
+	}
+	startTime := time.Now().UnixMilli()
+	result := &NodeResult{Name: node.Name, StartTime: startTime}
+	for {
+		result.Attempts++
+		handlerResult, running := handler.callNode(ctx, node, params)
+		result.Msg = handlerResult.Msg
+		result.Output = handlerResult.Output
+		if handlerResult.IsSuccess() {
+			result.State = NodeSuccess
+			break
+		}
+		result.State = NodeFailed
+		if result.Attempts > node.Retries || handlerResult.Category == task.CategoryPermanent || ctx.Err() != nil {
+			break
+		}
+
+		// 超时的处理方法还没有返回时等待其返回再重试，避免同一个节点同时执行多次
+		if running != nil {
+			select {
+			case <-running:
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		// 等待重试间隔，工作流被取消时不再重试
+		timer := time.NewTimer(time.Duration(node.RetryInterval) * time.Millisecond)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	result.ElapsedTime = int(time.Now().UnixMilli() - startTime)
+	return result
+}
+
+// callNode 执行一次节点，超时后返回失败，处理方法需要通过params.Context()感知超时并尽快返回。
+// 超时时处理方法可能还在执行，此时返回的running在处理方法返回后可读，否则为nil
+func (handler *handler) callNode(ctx stdContext.Context, node *Node, params *task.TaskParams) (*task.HandlerResult, <-chan *task.HandlerResult) {
+	nodeFunc, err := handler.nodeFunc(node)
+	if err != nil {
+		return task.Failed(err.Error()), nil
+	}
+
+	nodeContext, cancel := ctx, stdContext.CancelFunc(func() {})
+	if node.Timeout > 0 {
+		nodeContext, cancel = stdContext.WithTimeout(ctx, time.Duration(node.Timeout)*time.Millisecond)
+	}
+	defer cancel()
+
+	nodeParams := &task.TaskParams{
+		Page:          params.Page,
+		Total:         params.Total,
+		TaskLogId:     params.TaskLogId,
+		TaskId:        params.TaskId,
+		Method:        params.Method + "#" + node.Name,
+		ExeType:       params.ExeType,
+		Cron:          params.Cron,
+		Tag:           params.Tag,
+		ExecutionTime: params.ExecutionTime,
+		ScheduleTime:  params.ScheduleTime,
+		Params:        node.Params,
+	}
+	if nodeParams.Params == "" {
+		nodeParams.Params = params.Params
+	}
+	nodeParams.SetContext(nodeContext)
+
+	done := make(chan *task.HandlerResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- task.Failed(fmt.Sprintf("node %s panic: %v\r\n\r\n%s", node.Name, r, string(debug.Stack())))
+			}
+		}()
+		result := nodeFunc(nodeParams)
+		if result == nil {
+			result = task.Failed("result is null, node: " + node.Name)
+		}
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		return result, nil
+	case <-nodeContext.Done():
+		return task.Failed(fmt.Sprintf("node %s canceled: %v", node.Name, stdContext.Cause(nodeContext))), done
+	}
+}
+
+// executionContext 工作流的执行上下文。timeout小于等于0时直接使用任务上下文；
+// 大于0时不再受任务上下文的超时时间限制，改为最多执行timeout，任务上下文因为其他原因取消时（例如停机超时）同样取消
+func executionContext(parent stdContext.Context, timeout time.Duration) (stdContext.Context, stdContext.CancelFunc) {
+	if timeout <= 0 {
+		return parent, func() {}
+	}
+	ctx, cancel := stdContext.WithCancelCause(stdContext.WithoutCancel(parent))
+	ctx, cancelTimeout := stdContext.WithTimeout(ctx, timeout)
+	stop := stdContext.AfterFunc(parent, func() {
+		if cause := stdContext.Cause(parent); !errors.Is(cause, stdContext.DeadlineExceeded) {
+			cancel(cause)
+		}
+	})
+	return ctx, func() {
+		stop()
+		cancelTimeout()
+		cancel(nil)
+	}
+}
+
+// nodeFunc 获取节点的处理方法
+func (handler *handler) nodeFunc(node *Node) (NodeFunc, error) {
+	if node.Handler != nil {
+		return node.Handler.Handle, nil
+	}
+	if nodeFunc, ok := handler.resolve(node.Task); ok {
+		return nodeFunc, nil
+	}
+	return nil, fmt.Errorf("task %s of node %s not found", node.Task, node.Name)
+}
@@ -0,0 +1,227 @@
+package workflow
+
+import (
+	stdContext "context"
+	"github.com/horacedh/cronjob-executor/task"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Created in 2026-10-18 23:40.
+// @author Horace
+
+// funcHandler 使用函数实现的任务处理器
+type funcHandler func(params *task.TaskParams) *task.HandlerResult
+
+// Handle 任务处理方法
+func (handler funcHandler) Handle(params *task.TaskParams) *task.HandlerResult {
+	return handler(params)
+}
+
+// recorder 记录节点的执行顺序
+type recorder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+// node 创建记录执行顺序的节点处理器
+func (recorder *recorder) node(name string, delay time.Duration, result *task.HandlerResult) task.TaskHandler {
+	return funcHandler(func(params *task.TaskParams) *task.HandlerResult {
+		time.Sleep(delay)
+		recorder.mu.Lock()
+		recorder.order = append(recorder.order, name)
+		recorder.mu.Unlock()
+		return result
+	})
+}
+
+// index 节点在执行顺序中的位置
+func (recorder *recorder) index(name string) int {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for i, executed := range recorder.order {
+		if executed == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// noTasks 没有已注册任务的查找方法
+func noTasks(string) (NodeFunc, bool) {
+	return nil, false
+}
+
+// TestValidate 测试工作流校验一次性返回所有问题，并检测依赖环
+func TestValidate(t *testing.T) {
+	handler := funcHandler(func(*task.TaskParams) *task.HandlerResult { return task.Success() })
+	err := (&Workflow{Name: "invalid", Nodes: []Node{
+		{Name: "a", Handler: handler, DependsOn: []string{"missing"}},
+		{Name: "a", Handler: handler},
+		{Name: "b"},
+		{Name: "c", Task: "unknown"},
+		{Name: "d", Handler: handler, Retries: -1},
+	}}).Validate(noTasks)
+	if err == nil {
+		t.Fatalf("invalid workflow should fail")
+	}
+	for _, problem := range []string{`dependency "missing" not found`, "duplicate name", "task or handler is required", "task unknown not found", "must not be negative"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("problem %q not reported, err: %v", problem, err)
+		}
+	}
+
+	err = (&Workflow{Name: "cycle", Nodes: []Node{
+		{Name: "a", Handler: handler},
+		{Name: "b", Handler: handler, DependsOn: []string{"a", "d"}},
+		{Name: "c", Handler: handler, DependsOn: []string{"b"}},
+		{Name: "d", Handler: handler, DependsOn: []string{"c"}},
+	}}).Validate(noTasks)
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle detected among nodes: b, c, d") {
+		t.Errorf("cycle should be detected, err: %v", err)
+	}
+}
+
+// TestWorkflow 测试按照依赖顺序执行节点，并行分支同时执行，失败节点重试，依赖失败节点的节点跳过
+func TestWorkflow(t *testing.T) {
+	recorder := &recorder{}
+	var flakyAttempts atomic.Int32
+	flaky := funcHandler(func(params *task.TaskParams) *task.HandlerResult {
+		if flakyAttempts.Add(1) < 3 {
+			return task.Failed("flaky")
+		}
+		return recorder.node("flaky", 0, task.Success().WithOutput(params.Params)).Handle(params)
+	})
+	resolve := func(name string) (NodeFunc, bool) {
+		if name == "registered" {
+			return recorder.node("registered", 0, task.Success()).Handle, true
+		}
+		return nil, false
+	}
+
+	workflow := &Workflow{Name: "etl", Nodes: []Node{
+		{Name: "extract", Task: "registered"},
+		{Name: "left", Handler: recorder.node("left", time.Millisecond*200, task.Success()), DependsOn: []string{"extract"}},
+		{Name: "right", Handler: recorder.node("right", time.Millisecond*200, task.Success()), DependsOn: []string{"extract"}},
+		{Name: "flaky", Handler: flaky, DependsOn: []string{"left", "right"}, Params: "node params", Retries: 2, RetryInterval: 10},
+		{Name: "broken", Handler: recorder.node("broken", 0, task.Failed("broken")), DependsOn: []string{"extract"}, Retries: 1},
+		{Name: "load", Handler: recorder.node("load", 0, task.Success()), DependsOn: []string{"flaky", "broken"}},
+	}}
+	if err := workflow.Validate(resolve); err != nil {
+		t.Fatalf("validate workflow failed, err: %v", err)
+	}
+
+	startTime := time.Now()
+	result := NewHandler(workflow, resolve).Handle(&task.TaskParams{Method: "app/workflow.etl.Handle", Params: "workflow params"})
+	if elapsed := time.Since(startTime); elapsed > time.Millisecond*350 {
+		t.Errorf("parallel branches should run concurrently, elapsed: %v", elapsed)
+	}
+	if result.IsSuccess() || !strings.Contains(result.Msg, "failed nodes:[broken], skipped nodes:[load]") {
+		t.Errorf("workflow should fail, result: %+v", result)
+	}
+	if result.Counters["success"] != 4 || result.Counters["failed"] != 1 || result.Counters["skipped"] != 1 {
+		t.Errorf("unexpected counters: %v", result.Counters)
+	}
+	if recorder.index("extract") != -1 || recorder.index("registered") != 0 || recorder.index("flaky") < recorder.index("left") || recorder.index("flaky") < recorder.index("right") {
+		t.Errorf("nodes not executed in dependency order: %v", recorder.order)
+	}
+
+	nodes := result.Output.(*Result).Nodes
+	if len(nodes) != 6 || nodes[0].Name != "extract" || nodes[5].Name != "load" {
+		t.Fatalf("node results should keep definition order: %v", nodes)
+	}
+	if flaky := nodes[3]; flaky.State != NodeSuccess || flaky.Attempts != 3 || flaky.Output != "node params" {
+		t.Errorf("flaky node should succeed after retries, result: %+v", flaky)
+	}
+	if broken := nodes[4]; broken.State != NodeFailed || broken.Attempts != 2 || broken.Msg != "broken" {
+		t.Errorf("broken node should fail after retries, result: %+v", broken)
+	}
+	if load := nodes[5]; load.State != NodeSkipped || load.Attempts != 0 {
+		t.Errorf("load node should be skipped, result: %+v", load)
+	}
+}
+
+// TestWorkflowTimeout 测试节点超时后失败，工作流取消后没有开始的节点跳过
+func TestWorkflowTimeout(t *testing.T) {
+	blocking := funcHandler(func(params *task.TaskParams) *task.HandlerResult {
+		<-params.Context().Done()
+		return task.Failed(stdContext.Cause(params.Context()).Error())
+	})
+	panicking := funcHandler(func(*task.TaskParams) *task.HandlerResult {
+		panic("boom")
+	})
+	result := NewHandler(&Workflow{Name: "timeout", Nodes: []Node{
+		{Name: "slow", Handler: blocking, Timeout: 50},
+		{Name: "panic", Handler: panicking},
+	}}, noTasks).Handle(&task.TaskParams{})
+	nodes := result.Output.(*Result).Nodes
+	if nodes[0].State != NodeFailed || !strings.Contains(nodes[0].Msg, "deadline exceeded") {
+		t.Errorf("slow node should time out, result: %+v", nodes[0])
+	}
+	if nodes[1].State != NodeFailed || !strings.Contains(nodes[1].Msg, "boom") {
+		t.Errorf("panic should fail the node, result: %+v", nodes[1])
+	}
+
+	// 最多同时执行一个节点，先执行的节点因为工作流超时失败，另一个节点跳过
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Millisecond*100)
+	defer cancel()
+	params := &task.TaskParams{}
+	params.SetContext(ctx)
+	result = NewHandler(&Workflow{Name: "canceled", MaxParallel: 1, Nodes: []Node{
+		{Name: "first", Handler: blocking},
+		{Name: "second", Handler: blocking},
+	}}, noTasks).Handle(params)
+	if result.Counters["failed"] != 1 || result.Counters["skipped"] != 1 {
+		t.Errorf("unexpected results after workflow timeout: %+v, counters: %v", result.Output, result.Counters)
+	}
+}
+
+// TestWorkflowRetry 测试不可重试的失败不再重试，节点超时后等待上一次执行返回再重试，工作流的执行超时时间可以超过任务超时时间
+func TestWorkflowRetry(t *testing.T) {
+	var running, maxRunning, attempts atomic.Int32
+	ignoring := funcHandler(func(params *task.TaskParams) *task.HandlerResult {
+		attempts.Add(1)
+		current := running.Add(1)
+		defer running.Add(-1)
+		if current > maxRunning.Load() {
+			maxRunning.Store(current)
+		}
+		time.Sleep(time.Millisecond * 100)
+		return task.Failed("too slow")
+	})
+	permanent := funcHandler(func(*task.TaskParams) *task.HandlerResult {
+		return task.FailedWithCode(2, "bad request", task.CategoryPermanent)
+	})
+	slow := funcHandler(func(params *task.TaskParams) *task.HandlerResult {
+		select {
+		case <-time.After(time.Millisecond * 150):
+			return task.Success()
+		case <-params.Context().Done():
+			return task.Failed(stdContext.Cause(params.Context()).Error())
+		}
+	})
+
+	// 任务超时时间为50毫秒，工作流的执行超时时间为1秒
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Millisecond*50)
+	defer cancel()
+	params := &task.TaskParams{}
+	params.SetContext(ctx)
+	result := NewHandler(&Workflow{Name: "retry", ExecutionTimeout: time.Second, Nodes: []Node{
+		{Name: "ignoring", Handler: ignoring, Timeout: 20, Retries: 2},
+		{Name: "permanent", Handler: permanent, Retries: 3},
+		{Name: "slow", Handler: slow},
+	}}, noTasks).Handle(params)
+	nodes := result.Output.(*Result).Nodes
+	if nodes[0].State != NodeFailed || nodes[0].Attempts != 3 || attempts.Load() != 3 || maxRunning.Load() != 1 {
+		t.Errorf("retry should wait for the timed out attempt, result: %+v, attempts: %d, max running: %d", nodes[0], attempts.Load(), maxRunning.Load())
+	}
+	if nodes[1].State != NodeFailed || nodes[1].Attempts != 1 {
+		t.Errorf("permanent failure should not be retried, result: %+v", nodes[1])
+	}
+	if nodes[2].State != NodeSuccess {
+		t.Errorf("workflow execution timeout should replace the task timeout, result: %+v", nodes[2])
+	}
+}